@@ -0,0 +1,119 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeletionPolicy describes what happens to the PostgreSQL object when its api resource is deleted
+//+kubebuilder:validation:Enum=Delete;Retain
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete drops the PostgreSQL object
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain leaves the PostgreSQL object untouched
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// ManagementPolicy describes whether the operator changes the PostgreSQL object or only observes it
+//+kubebuilder:validation:Enum=Manage;Observe
+type ManagementPolicy string
+
+const (
+	// ManagementPolicyManage creates, updates and deletes the PostgreSQL object to match the spec
+	ManagementPolicyManage ManagementPolicy = "Manage"
+	// ManagementPolicyObserve only reports the differences between the PostgreSQL object and the spec
+	ManagementPolicyObserve ManagementPolicy = "Observe"
+)
+
+// Condition types reported in the status of the api resources
+const (
+	// ConditionReady is True when the PostgreSQL object matches the spec
+	ConditionReady = "Ready"
+	// ConditionConnected is True when the PostgreSQL server could be reached
+	ConditionConnected = "Connected"
+	// ConditionSynced is True when the last reconcile applied the spec
+	ConditionSynced = "Synced"
+	// ConditionDegraded is True when the last reconcile failed
+	ConditionDegraded = "Degraded"
+	// ConditionDrifted is True when the last reconcile found the PostgreSQL object modified outside of the operator
+	// and reverted it to the spec, or found it differing from the spec under the Observe management policy
+	ConditionDrifted = "Drifted"
+	// ConditionSuspended is True when a disabled PostgreSQLAccount had its login removed and its sessions terminated
+	ConditionSuspended = "Suspended"
+)
+
+// ReconcileStatus is the part of the observed state shared by all the api resources
+type ReconcileStatus struct {
+	// ObservedGeneration is the generation of the spec last reconciled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSyncTime is the last time the spec was successfully applied
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Conditions holds the Ready, Connected, Synced, Degraded and Drifted conditions
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// SSLMode is the libpq sslmode used to connect to the PostgreSQL server
+//+kubebuilder:validation:Enum=disable;require;verify-ca;verify-full
+type SSLMode string
+
+const (
+	// SSLModeDisable connects without TLS
+	SSLModeDisable SSLMode = "disable"
+	// SSLModeRequire connects with TLS without verifying the server certificate
+	SSLModeRequire SSLMode = "require"
+	// SSLModeVerifyCA connects with TLS verifying the server certificate is signed by the CA
+	SSLModeVerifyCA SSLMode = "verify-ca"
+	// SSLModeVerifyFull connects with TLS verifying the server certificate and its host name
+	SSLModeVerifyFull SSLMode = "verify-full"
+)
+
+// TLSSpec configures the TLS connection to the PostgreSQL server
+type TLSSpec struct {
+	//+kubebuilder:default=require
+	SSLMode SSLMode `json:"sslMode,omitempty"`
+	// CA is the PEM bundle verifying the server certificate, the system roots are used when it is not set
+	CA *CABundleSource `json:"ca,omitempty"`
+	// ClientCertSecretRef points to the Secret holding the client certificate authenticating the operator
+	ClientCertSecretRef *ClientCertSecretRef `json:"clientCertSecretRef,omitempty"`
+}
+
+// CABundleSource selects the Secret or the ConfigMap holding a CA bundle, only one of them can be set
+type CABundleSource struct {
+	SecretKeyRef    *KeyRef `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *KeyRef `json:"configMapKeyRef,omitempty"`
+}
+
+// KeyRef selects a key of a Secret or a ConfigMap
+type KeyRef struct {
+	Name string `json:"name"`
+	//+kubebuilder:default=ca.crt
+	Key string `json:"key,omitempty"`
+}
+
+// ClientCertSecretRef references the Secret keys holding a PEM client certificate and its private key
+type ClientCertSecretRef struct {
+	Name string `json:"name"`
+	//+kubebuilder:default=tls.crt
+	CertKey string `json:"certKey,omitempty"`
+	//+kubebuilder:default=tls.key
+	KeyKey string `json:"keyKey,omitempty"`
+}
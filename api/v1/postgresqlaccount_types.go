@@ -25,17 +25,119 @@ type PostgreSQLAccountSpec struct {
 	PostgreSQLDatabaseName string `json:"postgreSQLDatabaseName,omitempty"`
 	Name                   string `json:"name,omitempty"`
 	Password               string `json:"password,omitempty"`
-	ValidUntil             string `json:"valid_until,omitempty"`
+	// PasswordSecretRef points to a Secret key in the same namespace holding the password.
+	// When set it takes precedence over Password.
+	PasswordSecretRef *PasswordSecretRef `json:"passwordSecretRef,omitempty"`
+	// GeneratePassword makes the operator generate a random password and store it in an owned Secret,
+	// the one referenced by PasswordSecretRef or <metadata.name>-password when there is no reference.
+	GeneratePassword bool   `json:"generatePassword,omitempty"`
+	ValidUntil       string `json:"valid_until,omitempty"`
+	// Login allows the role to log in, it defaults to true so the role is a user
+	//+kubebuilder:default=true
+	Login *bool `json:"login,omitempty"`
+	// Disabled suspends the account: the role is set NOLOGIN whatever Login says and its sessions are terminated.
+	// Setting it back to false restores Login, the grants and memberships of the role are kept meanwhile.
+	Disabled bool `json:"disabled,omitempty"`
+	// CreateDB allows the role to create databases
+	CreateDB bool `json:"createDB,omitempty"`
+	// CreateRole allows the role to create, alter and drop other roles
+	CreateRole bool `json:"createRole,omitempty"`
+	// Inherit makes the role use the privileges of the roles it is a member of, it defaults to true
+	//+kubebuilder:default=true
+	Inherit *bool `json:"inherit,omitempty"`
+	// Replication allows the role to connect in replication mode, setting it requires a superuser
+	Replication bool `json:"replication,omitempty"`
+	// BypassRLS makes the role bypass the row level security policies, setting it requires a superuser
+	BypassRLS bool `json:"bypassRLS,omitempty"`
+	// ConnectionLimit is the number of concurrent connections the role can open, -1 meaning no limit
+	//+kubebuilder:default=-1
+	//+kubebuilder:validation:Minimum=-1
+	ConnectionLimit *int32 `json:"connectionLimit,omitempty"`
+	// MemberOf lists the roles the role is a member of. Removing a role from the list revokes the membership,
+	// the memberships granted outside of the operator are left untouched.
+	//+listType=map
+	//+listMapKey=role
+	MemberOf []RoleMembership `json:"memberOf,omitempty"`
+	// Parameters are the configuration parameters set on the role with ALTER ROLE SET, like statement_timeout.
+	// When present the parameters of the role missing from it are reset, an empty map resetting them all.
+	//+optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// DatabaseParameters are the configuration parameters set on the role in the database of the
+	// PostgreSQLDatabase only, with ALTER ROLE IN DATABASE SET. They are reset like Parameters.
+	//+optional
+	DatabaseParameters map[string]string `json:"databaseParameters,omitempty"`
+	// ConnectionSecret configures the Secret published with the connection details once the account is ready
+	ConnectionSecret *ConnectionSecretSpec `json:"connectionSecret,omitempty"`
+	// DeletionPolicy Delete drops the role when the account is deleted, Retain keeps it. A role that existed
+	// before the account is never dropped.
+	//+kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// ReassignOwnedTo is the role receiving the objects owned by the account before it is dropped,
+	// defaults to the admin user of the PostgreSQLDatabase
+	ReassignOwnedTo string `json:"reassignOwnedTo,omitempty"`
+	// ManagementPolicy Observe reports the differences between the spec and the role in the Drifted condition
+	// without changing it, nor dropping it when the api resource is deleted
+	//+kubebuilder:default=Manage
+	ManagementPolicy ManagementPolicy `json:"managementPolicy,omitempty"`
+}
+
+// RoleMembership is the membership of the account in a role
+type RoleMembership struct {
+	Role string `json:"role"`
+	// AdminOption allows the account to grant the membership to other roles
+	AdminOption bool `json:"adminOption,omitempty"`
+	// InheritOption makes the account use the privileges of the role, the server decides from the inherit
+	// attribute of the account when it is not set. It requires PostgreSQL 16.
+	InheritOption *bool `json:"inheritOption,omitempty"`
+	// SetOption allows the account to SET ROLE to the role, the server allows it when it is not set.
+	// It requires PostgreSQL 16.
+	SetOption *bool `json:"setOption,omitempty"`
+}
+
+// PasswordSecretRef references the Secret key holding the account password
+type PasswordSecretRef struct {
+	Name string `json:"name"`
+	// Key defaults to "password"
+	Key string `json:"key,omitempty"`
+}
+
+// ConnectionSecretSpec configures the Secret holding the account connection details
+type ConnectionSecretSpec struct {
+	// Name defaults to <metadata.name>-connection
+	Name string `json:"name,omitempty"`
+	// Keys maps each Secret key to a Go template rendered with .Host, .Port, .Database, .Username, .Password,
+	// .URI and .JDBCURL. When set it replaces the default host, port, database, username, password, uri
+	// and jdbc-url keys.
+	Keys map[string]string `json:"keys,omitempty"`
 }
 
 // PostgreSQLAccountStatus defines the observed state of PostgreSQLAccount
 type PostgreSQLAccountStatus struct {
-	Ready bool   `json:"ready"`
-	Error string `json:"error"`
+	ReconcileStatus `json:",inline"`
+	// MemberOf lists the roles the operator made the role a member of, so the memberships removed from the spec
+	// are revoked
+	MemberOf []string `json:"memberOf,omitempty"`
+	// PasswordPolicyCheck records the password and valid_until last found to comply with the
+	// PostgreSQLPasswordPolicies, which are only enforced again once they change
+	PasswordPolicyCheck *PasswordPolicyCheck `json:"passwordPolicyCheck,omitempty"`
+}
+
+// PasswordPolicyCheck is a password and valid_until checked against the PostgreSQLPasswordPolicies
+type PasswordPolicyCheck struct {
+	// PasswordVerifier is the SCRAM-SHA-256 verifier of the checked password, empty when there is no password
+	PasswordVerifier string `json:"passwordVerifier,omitempty"`
+	ValidUntil       string `json:"validUntil,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+//+kubebuilder:printcolumn:name="Suspended",type="string",JSONPath=".status.conditions[?(@.type==\"Suspended\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Message",type="string",priority=1,JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+//+kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // PostgreSQLAccount is the Schema for the postgresqlaccounts API
 type PostgreSQLAccount struct {
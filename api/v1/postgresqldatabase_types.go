@@ -22,23 +22,58 @@ import (
 
 // PostgreSQLDatabaseSpec defines the desired state of PostgreSQLDatabase
 type PostgreSQLDatabaseSpec struct {
-	Address    string `json:"address"`
-	User       string `json:"user"`
-	Password   string `json:"password"`
-	Database   string `json:"database"`
-	Encoding   string `json:"encoding,omitempty"`
-	LC_Collate string `json:"lc_collate,omitempty"`
-	LC_CType   string `json:"lc_ctype,omitempty"`
+	// ServerRef is the name of the PostgreSQLServer holding the connection, it replaces Address and the credentials
+	ServerRef string `json:"serverRef,omitempty"`
+	Address   string `json:"address,omitempty"`
+	User      string `json:"user,omitempty"`
+	Password  string `json:"password,omitempty"`
+	// CredentialsSecretRef points to a Secret in the same namespace holding the admin credentials.
+	// When set it takes precedence over User and Password.
+	CredentialsSecretRef *CredentialsSecretRef `json:"credentialsSecretRef,omitempty"`
+	// TLS configures the encryption of the connection declared inline, reading its Secrets and ConfigMaps
+	// from the same namespace
+	TLS        *TLSSpec `json:"tls,omitempty"`
+	Database   string   `json:"database"`
+	Encoding   string   `json:"encoding,omitempty"`
+	LC_Collate string   `json:"lc_collate,omitempty"`
+	LC_CType   string   `json:"lc_ctype,omitempty"`
+	// Parameters are the configuration parameters set on the database with ALTER DATABASE SET, like search_path.
+	// When present the parameters of the database missing from it are reset, an empty map resetting them all.
+	//+optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// DeletionPolicy Delete terminates the open connections and drops the database when the api resource
+	// is deleted, Retain leaves the data untouched. A database that existed before the api resource can only be
+	// adopted with Retain, so a Delete policy never drops a database the operator did not create.
+	//+kubebuilder:default=Retain
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// ManagementPolicy Observe reports the differences between the spec and the database in the Drifted condition
+	// without changing it, nor dropping it when the api resource is deleted
+	//+kubebuilder:default=Manage
+	ManagementPolicy ManagementPolicy `json:"managementPolicy,omitempty"`
+}
+
+// CredentialsSecretRef references the Secret keys holding the admin user and password
+type CredentialsSecretRef struct {
+	Name string `json:"name"`
+	// UserKey defaults to "username"
+	UserKey string `json:"userKey,omitempty"`
+	// PasswordKey defaults to "password"
+	PasswordKey string `json:"passwordKey,omitempty"`
 }
 
 // PostgreSQLDatabaseStatus defines the observed state of PostgreSQLDatabase
 type PostgreSQLDatabaseStatus struct {
-	Ready bool   `json:"ready"`
-	Error string `json:"error"`
+	ReconcileStatus `json:",inline"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Message",type="string",priority=1,JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+//+kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // PostgreSQLDatabase is the Schema for the postgresqldatabases API
 type PostgreSQLDatabase struct {
@@ -26,16 +26,25 @@ type PostgreSQLGrantSpec struct {
 	Type                   []string `json:"type,omitempty"`
 	To                     string   `json:"to,omitempty"`
 	Schema                 string   `json:"schema,omitempty"`
+	// ManagementPolicy Observe reports the differences between the spec and the privileges in the Drifted condition
+	// without granting nor revoking any of them, not even when the api resource is deleted
+	//+kubebuilder:default=Manage
+	ManagementPolicy ManagementPolicy `json:"managementPolicy,omitempty"`
 }
 
 // PostgreSQLGrantStatus defines the observed state of PostgreSQLGrant
 type PostgreSQLGrantStatus struct {
-	Ready bool   `json:"ready"`
-	Error string `json:"error"`
+	ReconcileStatus `json:",inline"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Message",type="string",priority=1,JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+//+kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // PostgreSQLGrant is the Schema for the postgresqlgrants API
 type PostgreSQLGrant struct {
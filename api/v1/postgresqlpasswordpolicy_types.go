@@ -0,0 +1,69 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgreSQLPasswordPolicySpec defines the rules the passwords and expirations of the PostgreSQLAccounts must follow
+type PostgreSQLPasswordPolicySpec struct {
+	// Namespaces restricts the namespaces whose PostgreSQLAccounts follow the policy, all of them when empty
+	Namespaces []string `json:"namespaces,omitempty"`
+	// MinLength is the minimum number of characters of a password
+	//+kubebuilder:validation:Minimum=0
+	MinLength int32 `json:"minLength,omitempty"`
+	// RequireUppercase, RequireLowercase, RequireDigit and RequireSymbol require a password to contain at least
+	// one character of the class, a symbol being any character that is not a letter nor a digit
+	RequireUppercase bool `json:"requireUppercase,omitempty"`
+	RequireLowercase bool `json:"requireLowercase,omitempty"`
+	RequireDigit     bool `json:"requireDigit,omitempty"`
+	RequireSymbol    bool `json:"requireSymbol,omitempty"`
+	// ForbiddenPasswords are rejected whatever their case
+	ForbiddenPasswords []string `json:"forbiddenPasswords,omitempty"`
+	// MaxValidUntilDays requires valid_until to be set no further than this number of days from now
+	//+kubebuilder:validation:Minimum=1
+	MaxValidUntilDays *int32 `json:"maxValidUntilDays,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Min Length",type="integer",JSONPath=".spec.minLength"
+//+kubebuilder:printcolumn:name="Max Valid Until Days",type="integer",JSONPath=".spec.maxValidUntilDays"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PostgreSQLPasswordPolicy is the Schema for the postgresqlpasswordpolicies API. Every policy applying to the
+// namespace of a PostgreSQLAccount is enforced on it.
+type PostgreSQLPasswordPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PostgreSQLPasswordPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PostgreSQLPasswordPolicyList contains a list of PostgreSQLPasswordPolicy
+type PostgreSQLPasswordPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgreSQLPasswordPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PostgreSQLPasswordPolicy{}, &PostgreSQLPasswordPolicyList{})
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PostgreSQLServerSpec defines the desired state of PostgreSQLServer
+type PostgreSQLServerSpec struct {
+	Address string `json:"address"`
+	// CredentialsSecretRef points to the Secret holding the admin credentials
+	CredentialsSecretRef ServerCredentialsSecretRef `json:"credentialsSecretRef"`
+	// TLS configures the connection encryption, its Secrets and ConfigMaps are read from the namespace of the credentials
+	TLS *TLSSpec `json:"tls,omitempty"`
+	// AllowedNamespaces restricts the namespaces whose PostgreSQLDatabases can use the server, all of them when empty
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+}
+
+// ServerCredentialsSecretRef references the Secret keys holding the admin user and password of a PostgreSQLServer
+type ServerCredentialsSecretRef struct {
+	Namespace            string `json:"namespace"`
+	CredentialsSecretRef `json:",inline"`
+}
+
+// PostgreSQLServerStatus defines the observed state of PostgreSQLServer
+type PostgreSQLServerStatus struct {
+	ReconcileStatus `json:",inline"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Message",type="string",priority=1,JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+//+kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PostgreSQLServer is the Schema for the postgresqlservers API
+type PostgreSQLServer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgreSQLServerSpec   `json:"spec,omitempty"`
+	Status PostgreSQLServerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PostgreSQLServerList contains a list of PostgreSQLServer
+type PostgreSQLServerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgreSQLServer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PostgreSQLServer{}, &PostgreSQLServerList{})
+}
@@ -22,16 +22,139 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleSource) DeepCopyInto(out *CABundleSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(KeyRef)
+		**out = **in
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(KeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleSource.
+func (in *CABundleSource) DeepCopy() *CABundleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCertSecretRef) DeepCopyInto(out *ClientCertSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCertSecretRef.
+func (in *ClientCertSecretRef) DeepCopy() *ClientCertSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCertSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionSecretSpec) DeepCopyInto(out *ConnectionSecretSpec) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionSecretSpec.
+func (in *ConnectionSecretSpec) DeepCopy() *ConnectionSecretSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionSecretSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSecretRef) DeepCopyInto(out *CredentialsSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSecretRef.
+func (in *CredentialsSecretRef) DeepCopy() *CredentialsSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRef) DeepCopyInto(out *KeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRef.
+func (in *KeyRef) DeepCopy() *KeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordPolicyCheck) DeepCopyInto(out *PasswordPolicyCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordPolicyCheck.
+func (in *PasswordPolicyCheck) DeepCopy() *PasswordPolicyCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordPolicyCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordSecretRef) DeepCopyInto(out *PasswordSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordSecretRef.
+func (in *PasswordSecretRef) DeepCopy() *PasswordSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgreSQLAccount) DeepCopyInto(out *PostgreSQLAccount) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLAccount.
@@ -87,6 +210,52 @@ func (in *PostgreSQLAccountList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgreSQLAccountSpec) DeepCopyInto(out *PostgreSQLAccountSpec) {
 	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(PasswordSecretRef)
+		**out = **in
+	}
+	if in.Login != nil {
+		in, out := &in.Login, &out.Login
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Inherit != nil {
+		in, out := &in.Inherit, &out.Inherit
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ConnectionLimit != nil {
+		in, out := &in.ConnectionLimit, &out.ConnectionLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MemberOf != nil {
+		in, out := &in.MemberOf, &out.MemberOf
+		*out = make([]RoleMembership, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DatabaseParameters != nil {
+		in, out := &in.DatabaseParameters, &out.DatabaseParameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ConnectionSecret != nil {
+		in, out := &in.ConnectionSecret, &out.ConnectionSecret
+		*out = new(ConnectionSecretSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLAccountSpec.
@@ -102,6 +271,17 @@ func (in *PostgreSQLAccountSpec) DeepCopy() *PostgreSQLAccountSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgreSQLAccountStatus) DeepCopyInto(out *PostgreSQLAccountStatus) {
 	*out = *in
+	in.ReconcileStatus.DeepCopyInto(&out.ReconcileStatus)
+	if in.MemberOf != nil {
+		in, out := &in.MemberOf, &out.MemberOf
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PasswordPolicyCheck != nil {
+		in, out := &in.PasswordPolicyCheck, &out.PasswordPolicyCheck
+		*out = new(PasswordPolicyCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLAccountStatus.
@@ -119,8 +299,8 @@ func (in *PostgreSQLDatabase) DeepCopyInto(out *PostgreSQLDatabase) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabase.
@@ -176,6 +356,23 @@ func (in *PostgreSQLDatabaseList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgreSQLDatabaseSpec) DeepCopyInto(out *PostgreSQLDatabaseSpec) {
 	*out = *in
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(CredentialsSecretRef)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseSpec.
@@ -191,6 +388,7 @@ func (in *PostgreSQLDatabaseSpec) DeepCopy() *PostgreSQLDatabaseSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgreSQLDatabaseStatus) DeepCopyInto(out *PostgreSQLDatabaseStatus) {
 	*out = *in
+	in.ReconcileStatus.DeepCopyInto(&out.ReconcileStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLDatabaseStatus.
@@ -209,7 +407,7 @@ func (in *PostgreSQLGrant) DeepCopyInto(out *PostgreSQLGrant) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLGrant.
@@ -285,6 +483,7 @@ func (in *PostgreSQLGrantSpec) DeepCopy() *PostgreSQLGrantSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgreSQLGrantStatus) DeepCopyInto(out *PostgreSQLGrantStatus) {
 	*out = *in
+	in.ReconcileStatus.DeepCopyInto(&out.ReconcileStatus)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLGrantStatus.
@@ -296,3 +495,284 @@ func (in *PostgreSQLGrantStatus) DeepCopy() *PostgreSQLGrantStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLPasswordPolicy) DeepCopyInto(out *PostgreSQLPasswordPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLPasswordPolicy.
+func (in *PostgreSQLPasswordPolicy) DeepCopy() *PostgreSQLPasswordPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLPasswordPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLPasswordPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLPasswordPolicyList) DeepCopyInto(out *PostgreSQLPasswordPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PostgreSQLPasswordPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLPasswordPolicyList.
+func (in *PostgreSQLPasswordPolicyList) DeepCopy() *PostgreSQLPasswordPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLPasswordPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLPasswordPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLPasswordPolicySpec) DeepCopyInto(out *PostgreSQLPasswordPolicySpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForbiddenPasswords != nil {
+		in, out := &in.ForbiddenPasswords, &out.ForbiddenPasswords
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxValidUntilDays != nil {
+		in, out := &in.MaxValidUntilDays, &out.MaxValidUntilDays
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLPasswordPolicySpec.
+func (in *PostgreSQLPasswordPolicySpec) DeepCopy() *PostgreSQLPasswordPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLPasswordPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLServer) DeepCopyInto(out *PostgreSQLServer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLServer.
+func (in *PostgreSQLServer) DeepCopy() *PostgreSQLServer {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLServer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLServerList) DeepCopyInto(out *PostgreSQLServerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PostgreSQLServer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLServerList.
+func (in *PostgreSQLServerList) DeepCopy() *PostgreSQLServerList {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLServerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgreSQLServerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLServerSpec) DeepCopyInto(out *PostgreSQLServerSpec) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLServerSpec.
+func (in *PostgreSQLServerSpec) DeepCopy() *PostgreSQLServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgreSQLServerStatus) DeepCopyInto(out *PostgreSQLServerStatus) {
+	*out = *in
+	in.ReconcileStatus.DeepCopyInto(&out.ReconcileStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgreSQLServerStatus.
+func (in *PostgreSQLServerStatus) DeepCopy() *PostgreSQLServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgreSQLServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileStatus) DeepCopyInto(out *ReconcileStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileStatus.
+func (in *ReconcileStatus) DeepCopy() *ReconcileStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleMembership) DeepCopyInto(out *RoleMembership) {
+	*out = *in
+	if in.InheritOption != nil {
+		in, out := &in.InheritOption, &out.InheritOption
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SetOption != nil {
+		in, out := &in.SetOption, &out.SetOption
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleMembership.
+func (in *RoleMembership) DeepCopy() *RoleMembership {
+	if in == nil {
+		return nil
+	}
+	out := new(RoleMembership)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerCredentialsSecretRef) DeepCopyInto(out *ServerCredentialsSecretRef) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerCredentialsSecretRef.
+func (in *ServerCredentialsSecretRef) DeepCopy() *ServerCredentialsSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerCredentialsSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
+	*out = *in
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = new(CABundleSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientCertSecretRef != nil {
+		in, out := &in.ClientCertSecretRef, &out.ClientCertSecretRef
+		*out = new(ClientCertSecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSpec.
+func (in *TLSSpec) DeepCopy() *TLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
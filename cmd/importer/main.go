@@ -0,0 +1,407 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// importer reads the databases, login roles and table grants of an existing PostgreSQL server and prints the
+// PostgreSQLDatabase, PostgreSQLAccount and PostgreSQLGrant resources describing them, so they can be adopted
+// by the operator without any change on the server.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	_ "github.com/lib/pq"
+	"sigs.k8s.io/yaml"
+
+	v1 "database-account-operator/api/v1"
+	"database-account-operator/pkg/pgsql"
+)
+
+// manifest is the part of an api resource written by the importer, leaving out the status
+type manifest struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Metadata   objectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec"`
+}
+
+type objectMeta struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type options struct {
+	address, user, sslMode string
+	namespace, serverRef   string
+	credentialsSecret      string
+	accountDatabase        string
+	excludeDatabases       map[string]bool
+	managementPolicy       v1.ManagementPolicy
+}
+
+func main() {
+	var o options
+	var excludeDatabases, managementPolicy string
+	flag.StringVar(&o.address, "address", "localhost:5432", "The host:port of the PostgreSQL server to import.")
+	flag.StringVar(&o.user, "user", "postgres", "The admin user connecting to the server, its password is read from PGPASSWORD.")
+	flag.StringVar(&o.sslMode, "sslmode", "disable", "The libpq sslmode used to connect to the server.")
+	flag.StringVar(&o.namespace, "namespace", "default", "The namespace of the generated resources.")
+	flag.StringVar(&o.serverRef, "server-ref", "",
+		"The PostgreSQLServer referenced by the generated PostgreSQLDatabases, they declare their connection inline when empty.")
+	flag.StringVar(&o.credentialsSecret, "credentials-secret", "postgres-credentials",
+		"The Secret holding the admin credentials of the PostgreSQLDatabases declaring their connection inline.")
+	flag.StringVar(&o.accountDatabase, "account-database", "",
+		"The database of the PostgreSQLAccounts whose role owns no database, defaults to the first imported database.")
+	flag.StringVar(&excludeDatabases, "exclude-databases", "postgres", "Comma separated databases not to import.")
+	flag.StringVar(&managementPolicy, "management-policy", string(v1.ManagementPolicyObserve),
+		"The managementPolicy of the generated resources, Observe or Manage.")
+	flag.Parse()
+
+	o.excludeDatabases = map[string]bool{}
+	for _, database := range strings.Split(excludeDatabases, ",") {
+		o.excludeDatabases[database] = true
+	}
+	o.managementPolicy = v1.ManagementPolicy(managementPolicy)
+	if o.managementPolicy != v1.ManagementPolicyObserve && o.managementPolicy != v1.ManagementPolicyManage {
+		fmt.Fprintf(os.Stderr, "invalid management policy %s\n", managementPolicy)
+		os.Exit(1)
+	}
+	if err := run(&o, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(o *options, out io.Writer) error {
+	admin, err := sql.Open("postgres", connectionString(o, ""))
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	databases, owners, err := readDatabases(admin, o)
+	if err != nil {
+		return err
+	}
+	if len(databases) == 0 {
+		return fmt.Errorf(`no database to import on %s`, o.address)
+	}
+	accounts, err := readAccounts(admin, o, databases, owners)
+	if err != nil {
+		return err
+	}
+	manifests := append(databases, accounts...)
+	for _, db := range databases {
+		grants, err := readGrants(o, db.Spec.(*v1.PostgreSQLDatabaseSpec).Database, db.Metadata.Name)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, grants...)
+	}
+	// the names differing only by their case or underscores share their resource name
+	names := map[string]bool{}
+	for _, m := range manifests {
+		if names[m.Kind+"/"+m.Metadata.Name] {
+			return fmt.Errorf(`several %ss are named %s, rename the PostgreSQL objects they import`, m.Kind, m.Metadata.Name)
+		}
+		names[m.Kind+"/"+m.Metadata.Name] = true
+	}
+	for _, m := range manifests {
+		data, err := yaml.Marshal(m)
+		if err != nil {
+			return fmt.Errorf(`error marshalling %s %s : %w`, m.Kind, m.Metadata.Name, err)
+		}
+		if _, err = fmt.Fprintf(out, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func connectionString(o *options, database string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(o.user, os.Getenv("PGPASSWORD")),
+		Host:     o.address,
+		RawQuery: url.Values{"sslmode": []string{o.sslMode}}.Encode(),
+	}
+	if database != "" {
+		u.Path = "/" + database
+	}
+	return u.String()
+}
+
+// readDatabases returns the PostgreSQLDatabases to import and the role owning each of them
+func readDatabases(admin *sql.DB, o *options) ([]manifest, map[string]string, error) {
+	query := `SELECT d.datname, pg_encoding_to_char(d.encoding), d.datcollate, d.datctype, r.rolname
+		FROM pg_catalog.pg_database d JOIN pg_catalog.pg_roles r ON r.oid = d.datdba
+		WHERE NOT d.datistemplate AND d.datallowconn ORDER BY d.datname`
+	rows, err := admin.Query(query)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`error executing query %s : %w`, query, err)
+	}
+	defer rows.Close()
+	var databases []manifest
+	owners := map[string]string{}
+	for rows.Next() {
+		spec := &v1.PostgreSQLDatabaseSpec{
+			DeletionPolicy:   v1.DeletionPolicyRetain,
+			ManagementPolicy: o.managementPolicy,
+		}
+		var owner string
+		if err = rows.Scan(&spec.Database, &spec.Encoding, &spec.LC_Collate, &spec.LC_CType, &owner); err != nil {
+			return nil, nil, fmt.Errorf(`error reading databases : %w`, err)
+		}
+		if o.excludeDatabases[spec.Database] {
+			continue
+		}
+		if !pgsql.ValidName(spec.Database) {
+			warn("skipping database %s, its name is not accepted by the validation", spec.Database)
+			continue
+		}
+		// an omitted locale is not compared with the one of the database
+		for _, locale := range []*string{&spec.LC_Collate, &spec.LC_CType} {
+			if !pgsql.ValidLocale(*locale) {
+				warn("omitting locale %s of database %s, it is not accepted by the validation", *locale, spec.Database)
+				*locale = ""
+			}
+		}
+		if o.serverRef != "" {
+			spec.ServerRef = o.serverRef
+		} else {
+			spec.Address = o.address
+			spec.CredentialsSecretRef = &v1.CredentialsSecretRef{Name: o.credentialsSecret}
+			if o.sslMode != string(v1.SSLModeDisable) {
+				spec.TLS = &v1.TLSSpec{SSLMode: v1.SSLMode(o.sslMode)}
+			}
+		}
+		databases = append(databases, newManifest("PostgreSQLDatabase", o, resourceName(spec.Database), spec))
+		owners[owner] = resourceName(spec.Database)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf(`error iterating databases : %w`, err)
+	}
+	return databases, owners, nil
+}
+
+// readAccounts returns a PostgreSQLAccount for each login role, in the PostgreSQLDatabase of the database the
+// role owns. Their passwords are read from a Secret named after the account, which has to be created before
+// switching them to the Manage policy.
+func readAccounts(admin *sql.DB, o *options, databases []manifest, owners map[string]string) ([]manifest, error) {
+	defaultDatabase := databases[0].Metadata.Name
+	if o.accountDatabase != "" {
+		defaultDatabase = resourceName(o.accountDatabase)
+	}
+	query := `SELECT rolname, COALESCE(to_char(rolvaliduntil, 'YYYY-MM-DD'), ''), rolcreatedb, rolcreaterole, rolinherit,
+		rolreplication, rolbypassrls, rolconnlimit FROM pg_catalog.pg_roles
+		WHERE rolcanlogin AND NOT rolsuper AND rolname !~ '^pg_' AND rolname <> current_user ORDER BY rolname`
+	rows, err := admin.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf(`error executing query %s : %w`, query, err)
+	}
+	defer rows.Close()
+	var accounts []manifest
+	for rows.Next() {
+		spec := &v1.PostgreSQLAccountSpec{
+			DeletionPolicy:   v1.DeletionPolicyRetain,
+			ManagementPolicy: o.managementPolicy,
+		}
+		var inherit bool
+		var connectionLimit int32
+		if err = rows.Scan(&spec.Name, &spec.ValidUntil, &spec.CreateDB, &spec.CreateRole, &inherit,
+			&spec.Replication, &spec.BypassRLS, &connectionLimit); err != nil {
+			return nil, fmt.Errorf(`error reading roles : %w`, err)
+		}
+		// the attributes with their default value are left out
+		if !inherit {
+			spec.Inherit = &inherit
+		}
+		if connectionLimit != -1 {
+			spec.ConnectionLimit = &connectionLimit
+		}
+		if !pgsql.ValidName(spec.Name) {
+			warn("skipping role %s, its name is not accepted by the validation", spec.Name)
+			continue
+		}
+		spec.PostgreSQLDatabaseName = defaultDatabase
+		if db, ok := owners[spec.Name]; ok {
+			spec.PostgreSQLDatabaseName = db
+		}
+		name := resourceName(spec.Name)
+		spec.PasswordSecretRef = &v1.PasswordSecretRef{Name: name + "-password"}
+		accounts = append(accounts, newManifest("PostgreSQLAccount", o, name, spec))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf(`error iterating roles : %w`, err)
+	}
+	return accounts, nil
+}
+
+// readGrants returns a PostgreSQLGrant for each grantee and schema of the database, holding the privileges it was
+// granted on every table of the schema, so the reconciler neither grants nor revokes anything. It fails when
+// the grantee was also granted privileges a PostgreSQLGrant can not hold, which the reconciler would revoke.
+func readGrants(o *options, database, dbResourceName string) ([]manifest, error) {
+	db, err := sql.Open("postgres", connectionString(o, database))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := countTables(db)
+	if err != nil {
+		return nil, err
+	}
+	// the privileges of the owners are granted by themselves, they are held but never revoked by the reconciler
+	query := `SELECT grantee, table_schema, privilege_type, count(DISTINCT table_name),
+		count(DISTINCT table_name) FILTER (WHERE grantor <> grantee)
+		FROM information_schema.role_table_grants
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+		GROUP BY grantee, table_schema, privilege_type ORDER BY grantee, table_schema`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf(`error executing query %s on database %s : %w`, query, database, err)
+	}
+	defer rows.Close()
+	type grantKey struct{ grantee, schema string }
+	var keys []grantKey
+	held, granted := map[grantKey]map[string]int{}, map[grantKey]map[string]int{}
+	for rows.Next() {
+		var key grantKey
+		var privilege string
+		var heldTables, grantedTables int
+		if err = rows.Scan(&key.grantee, &key.schema, &privilege, &heldTables, &grantedTables); err != nil {
+			return nil, fmt.Errorf(`error reading grants of database %s : %w`, database, err)
+		}
+		// the PostgreSQLGrant reconciler folds the schema names to lower case
+		if !pgsql.ValidName(key.grantee) || !pgsql.ValidName(key.schema) || key.schema != strings.ToLower(key.schema) {
+			if grantedTables > 0 {
+				warn("skipping %s privilege of %s in schema %s of database %s, their names are not accepted by the validation",
+					privilege, key.grantee, key.schema, database)
+			}
+			continue
+		}
+		if _, ok := held[key]; !ok {
+			keys = append(keys, key)
+			held[key], granted[key] = map[string]int{}, map[string]int{}
+		}
+		held[key][privilege], granted[key][privilege] = heldTables, grantedTables
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf(`error iterating grants of database %s : %w`, database, err)
+	}
+	var grants []manifest
+	var conflicts []string
+	for _, key := range keys {
+		types, unexpressed := grantTypes(held[key], granted[key], tables[key.schema])
+		if len(unexpressed) > 0 {
+			if len(types) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf(`%s of %s in schema %s of database %s`,
+					strings.Join(unexpressed, ", "), key.grantee, key.schema, database))
+				continue
+			}
+			// without a PostgreSQLGrant for the grantee and schema they are left untouched
+			warn("not importing %s privileges of %s in schema %s of database %s, a PostgreSQLGrant can not hold them",
+				strings.Join(unexpressed, ", "), key.grantee, key.schema, database)
+		}
+		if len(types) == 0 {
+			continue
+		}
+		spec := &v1.PostgreSQLGrantSpec{
+			PostgreSQLDatabaseName: dbResourceName,
+			Type:                   types,
+			To:                     key.grantee,
+			Schema:                 key.schema,
+			ManagementPolicy:       o.managementPolicy,
+		}
+		grants = append(grants, newManifest("PostgreSQLGrant", o, resourceName(database, key.schema, key.grantee), spec))
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf(`the PostgreSQLGrants would revoke the privileges %s, as they are only granted on some `+
+			`of the tables of the schema or are REFERENCES or TRIGGER without all the others, grant them on every table `+
+			`or revoke them before importing`, strings.Join(conflicts, "; "))
+	}
+	return grants, nil
+}
+
+// countTables returns the number of tables of each schema, counted the same way as the PostgreSQLGrant reconciler
+func countTables(db *sql.DB) (map[string]int, error) {
+	query := `SELECT table_schema, count(*) FROM information_schema.tables GROUP BY table_schema`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf(`error executing query %s : %w`, query, err)
+	}
+	defer rows.Close()
+	result := map[string]int{}
+	for rows.Next() {
+		var schema string
+		var count int
+		if err = rows.Scan(&schema, &count); err != nil {
+			return nil, fmt.Errorf(`error reading tables : %w`, err)
+		}
+		result[schema] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf(`error iterating tables : %w`, err)
+	}
+	return result, nil
+}
+
+// grantTypes returns the type of a PostgreSQLGrant holding the privileges granted by another role on every table
+// of a schema, ALL when every privilege is held on all of them, and the granted privileges it can not hold. The
+// privileges only held as the owner of the tables are left out, the reconciler does not revoke them.
+func grantTypes(held, granted map[string]int, tables int) (types, unexpressed []string) {
+	all := true
+	for _, privilege := range pgsql.TablePrivileges {
+		all = all && held[privilege] >= tables
+	}
+	for _, privilege := range pgsql.TablePrivileges {
+		if granted[privilege] == 0 {
+			continue
+		}
+		if all {
+			return []string{"ALL"}, nil
+		}
+		if held[privilege] < tables || privilege == "REFERENCES" || privilege == "TRIGGER" {
+			unexpressed = append(unexpressed, privilege)
+		} else {
+			types = append(types, privilege)
+		}
+	}
+	return types, unexpressed
+}
+
+func newManifest(kind string, o *options, name string, spec interface{}) manifest {
+	return manifest{
+		APIVersion: v1.GroupVersion.String(),
+		Kind:       kind,
+		Metadata:   objectMeta{Name: name, Namespace: o.namespace},
+		Spec:       spec,
+	}
+}
+
+// resourceName turns PostgreSQL names into a valid api resource name
+func resourceName(parts ...string) string {
+	return strings.Trim(strings.ReplaceAll(strings.ToLower(strings.Join(parts, "-")), "_", "-"), "-")
+}
+
+func warn(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
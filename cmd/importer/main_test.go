@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGrantTypes(t *testing.T) {
+	all := func(tables int) map[string]int {
+		return map[string]int{"SELECT": tables, "INSERT": tables, "UPDATE": tables, "DELETE": tables,
+			"TRUNCATE": tables, "REFERENCES": tables, "TRIGGER": tables}
+	}
+	tests := []struct {
+		name            string
+		held, granted   map[string]int
+		types, rejected []string
+	}{
+		{
+			name:    "privileges granted on every table",
+			held:    map[string]int{"SELECT": 2, "UPDATE": 2},
+			granted: map[string]int{"SELECT": 2, "UPDATE": 2},
+			types:   []string{"SELECT", "UPDATE"},
+		},
+		{
+			name:    "every privilege granted on every table",
+			held:    all(2),
+			granted: all(2),
+			types:   []string{"ALL"},
+		},
+		{
+			name:    "owner of a table granted every privilege on the other one",
+			held:    all(2),
+			granted: all(1),
+			types:   []string{"ALL"},
+		},
+		{
+			name:    "owner of a table granted SELECT on the other one",
+			held:    map[string]int{"SELECT": 2, "INSERT": 1, "UPDATE": 1, "DELETE": 1, "TRUNCATE": 1, "REFERENCES": 1, "TRIGGER": 1},
+			granted: map[string]int{"SELECT": 1},
+			types:   []string{"SELECT"},
+		},
+		{
+			name:    "owner of every table",
+			held:    all(2),
+			granted: map[string]int{},
+		},
+		{
+			name:     "privilege granted on some of the tables",
+			held:     map[string]int{"SELECT": 2, "INSERT": 1},
+			granted:  map[string]int{"SELECT": 2, "INSERT": 1},
+			types:    []string{"SELECT"},
+			rejected: []string{"INSERT"},
+		},
+		{
+			name:     "REFERENCES without the other privileges",
+			held:     map[string]int{"SELECT": 2, "REFERENCES": 2},
+			granted:  map[string]int{"SELECT": 2, "REFERENCES": 2},
+			types:    []string{"SELECT"},
+			rejected: []string{"REFERENCES"},
+		},
+		{
+			name:     "only privileges a grant can not hold",
+			held:     map[string]int{"TRIGGER": 2, "INSERT": 1},
+			granted:  map[string]int{"TRIGGER": 2, "INSERT": 1},
+			rejected: []string{"INSERT", "TRIGGER"},
+		},
+	}
+	for _, tt := range tests {
+		types, rejected := grantTypes(tt.held, tt.granted, 2)
+		if !reflect.DeepEqual(types, tt.types) || !reflect.DeepEqual(rejected, tt.rejected) {
+			t.Errorf("%s: grantTypes = %v, %v, want %v, %v", tt.name, types, rejected, tt.types, tt.rejected)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "database-account-operator/api/v1"
+	"database-account-operator/pkg/pgsql"
+)
+
+// serverConnectionKey is the ConnectionRegistry key of the admin connection of a PostgreSQLServer
+func serverConnectionKey(server string) string {
+	return "PostgreSQLServer/" + server
+}
+
+// inlineConnectionKey is the ConnectionRegistry key of the admin connection declared in a PostgreSQLDatabase spec
+func inlineConnectionKey(namespacedName *types.NamespacedName) string {
+	return "PostgreSQLDatabase/" + namespacedName.String()
+}
+
+// connectionKey is the ConnectionRegistry key of the admin connection used by a PostgreSQLDatabase
+func connectionKey(namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec) string {
+	if dbSpec.ServerRef != "" {
+		return serverConnectionKey(dbSpec.ServerRef)
+	}
+	return inlineConnectionKey(namespacedName)
+}
+
+// databaseConnectionKey is the ConnectionRegistry key of the connection to a database of the server behind adminKey
+func databaseConnectionKey(adminKey string, database string) string {
+	return adminKey + "/" + database
+}
+
+// serverConnection holds the settings to connect to the server hosting a PostgreSQLDatabase
+type serverConnection struct {
+	user     string
+	password string
+	address  string
+	tls      *tlsParams
+}
+
+// connectionString connects to the database, or to the default database of the user when it is empty
+func (c *serverConnection) connectionString(database string) string {
+	query := url.Values{"sslmode": {string(c.tls.sslMode)}}
+	if c.tls.rootCert != "" {
+		query.Set("sslrootcert", c.tls.rootCert)
+	}
+	if c.tls.cert != "" {
+		query.Set("sslcert", c.tls.cert)
+		query.Set("sslkey", c.tls.key)
+	}
+	connURL := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(c.user, c.password),
+		Host:     c.address,
+		RawQuery: query.Encode(),
+	}
+	if database != "" {
+		connURL.Path = "/" + database
+	}
+	return connURL.String()
+}
+
+// acquireDatabase returns the connection to the database of the PostgreSQLDatabase and the function releasing it.
+// The connection is opened from the PostgreSQLDatabase spec and credentials when the registry does not hold it,
+// so it does not depend on the PostgreSQLDatabase having been reconciled since the operator started.
+func acquireDatabase(ctx context.Context, c client.Client, connections *ConnectionRegistry, dbNamespacedName *types.NamespacedName) (*sql.DB, func(), error) {
+	db := &v1.PostgreSQLDatabase{}
+	if err := c.Get(ctx, *dbNamespacedName, db); err != nil {
+		err = fmt.Errorf(`error reading PostgreSQLDatabase %s : %w`, dbNamespacedName.String(), err)
+		if apierrors.IsNotFound(err) {
+			return nil, nil, dependencyError(err)
+		}
+		return nil, nil, err
+	}
+	if err := validateDatabase(&db.Spec); err != nil {
+		return nil, nil, fmt.Errorf(`invalid PostgreSQLDatabase %s : %w`, dbNamespacedName.String(), err)
+	}
+	conn, err := resolveConnection(ctx, c, dbNamespacedName.Namespace, &db.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := databaseConnectionKey(connectionKey(dbNamespacedName, &db.Spec), db.Spec.Database)
+	if err = connections.Open(key, conn.connectionString(db.Spec.Database)); err != nil {
+		return nil, nil, err
+	}
+	dbClient, release, ok := connections.Acquire(key)
+	if !ok {
+		return nil, nil, fmt.Errorf(`connection to PostgreSQLDatabase %s was closed`, dbNamespacedName.String())
+	}
+	if err = dbClient.PingContext(ctx); err != nil {
+		release()
+		// the database may just not be created yet
+		if !meta.IsStatusConditionTrue(db.Status.Conditions, v1.ConditionReady) {
+			return nil, nil, dependencyError(fmt.Errorf(`PostgreSQLDatabase %s is not ready : %w`, dbNamespacedName.String(), err))
+		}
+		return nil, nil, err
+	}
+	return dbClient, release, nil
+}
+
+// resolveServerConnection reads the credentials and certificates of the PostgreSQLServer
+func resolveServerConnection(ctx context.Context, c client.Client, server *v1.PostgreSQLServer) (*serverConnection, error) {
+	ref := &server.Spec.CredentialsSecretRef
+	user, password, err := readCredentials(ctx, c, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, &ref.CredentialsSecretRef)
+	if err != nil {
+		return nil, err
+	}
+	tls, err := readTLS(ctx, c, ref.Namespace, server.Spec.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &serverConnection{user: user, password: password, address: server.Spec.Address, tls: tls}, nil
+}
+
+// resolveConnection returns the settings to connect to the server hosting the database, read from its PostgreSQLServer
+// when it references one or from its inline connection otherwise
+func resolveConnection(ctx context.Context, c client.Client, namespace string, dbSpec *v1.PostgreSQLDatabaseSpec) (*serverConnection, error) {
+	if dbSpec.ServerRef != "" {
+		server := &v1.PostgreSQLServer{}
+		if err := c.Get(ctx, types.NamespacedName{Name: dbSpec.ServerRef}, server); err != nil {
+			err = fmt.Errorf(`error reading PostgreSQLServer %s : %w`, dbSpec.ServerRef, err)
+			if apierrors.IsNotFound(err) {
+				return nil, dependencyError(err)
+			}
+			return nil, err
+		}
+		if len(server.Spec.AllowedNamespaces) > 0 && !containsString(server.Spec.AllowedNamespaces, namespace) {
+			return nil, fmt.Errorf(`namespace %s is not allowed to use PostgreSQLServer %s`, namespace, dbSpec.ServerRef)
+		}
+		return resolveServerConnection(ctx, c, server)
+	}
+	conn := &serverConnection{user: dbSpec.User, password: dbSpec.Password, address: dbSpec.Address}
+	if ref := dbSpec.CredentialsSecretRef; ref != nil {
+		var err error
+		conn.user, conn.password, err = readCredentials(ctx, c, types.NamespacedName{Name: ref.Name, Namespace: namespace}, ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tls, err := readTLS(ctx, c, namespace, dbSpec.TLS)
+	if err != nil {
+		return nil, err
+	}
+	conn.tls = tls
+	return conn, nil
+}
+
+// tlsParams holds the libpq ssl settings of a connection, the certificates are paths of the files written by writePEM
+type tlsParams struct {
+	sslMode  v1.SSLMode
+	rootCert string
+	cert     string
+	key      string
+}
+
+// readTLS reads the certificates referenced by spec from the namespace and writes them where libpq can load them.
+// A nil spec disables TLS.
+func readTLS(ctx context.Context, c client.Client, namespace string, spec *v1.TLSSpec) (*tlsParams, error) {
+	if spec == nil {
+		return &tlsParams{sslMode: v1.SSLModeDisable}, nil
+	}
+	params := &tlsParams{sslMode: spec.SSLMode}
+	if params.sslMode == "" {
+		params.sslMode = v1.SSLModeRequire
+	}
+	if ca := spec.CA; ca != nil {
+		var bundle string
+		var err error
+		if ref := ca.SecretKeyRef; ref != nil {
+			bundle, err = readSecretKey(ctx, c, types.NamespacedName{Name: ref.Name, Namespace: namespace}, keyOrDefault(ref.Key, "ca.crt"))
+		} else if ref := ca.ConfigMapKeyRef; ref != nil {
+			bundle, err = readConfigMapKey(ctx, c, types.NamespacedName{Name: ref.Name, Namespace: namespace}, keyOrDefault(ref.Key, "ca.crt"))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if params.rootCert, err = writePEM(bundle); err != nil {
+			return nil, err
+		}
+	}
+	if ref := spec.ClientCertSecretRef; ref != nil {
+		secretName := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+		cert, err := readSecretKey(ctx, c, secretName, keyOrDefault(ref.CertKey, "tls.crt"))
+		if err != nil {
+			return nil, err
+		}
+		key, err := readSecretKey(ctx, c, secretName, keyOrDefault(ref.KeyKey, "tls.key"))
+		if err != nil {
+			return nil, err
+		}
+		if params.cert, err = writePEM(cert); err != nil {
+			return nil, err
+		}
+		if params.key, err = writePEM(key); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+func keyOrDefault(key, defaultKey string) string {
+	if key == "" {
+		return defaultKey
+	}
+	return key
+}
+
+func readConfigMapKey(ctx context.Context, c client.Client, configMapName types.NamespacedName, key string) (string, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, configMapName, configMap); err != nil {
+		return "", fmt.Errorf(`error reading configmap %s : %w`, configMapName.String(), err)
+	}
+	value, ok := configMap.Data[key]
+	if !ok {
+		return "", fmt.Errorf(`key %s not found in configmap %s`, key, configMapName.String())
+	}
+	return value, nil
+}
+
+// tlsDir holds the certificates of the connections, lib/pq only loads the inline ones together with a client certificate
+var tlsDir = filepath.Join(os.TempDir(), "database-account-operator")
+
+// writePEM writes content to a file named after its hash and returns its path, so a rotated certificate
+// changes the connection string and reopens the connection
+func writePEM(content string) (string, error) {
+	sum := sha256.Sum256([]byte(content))
+	path := filepath.Join(tlsDir, hex.EncodeToString(sum[:])+".pem")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(tlsDir, 0700); err != nil {
+		return "", fmt.Errorf(`error creating directory %s : %w`, tlsDir, err)
+	}
+	// the file is renamed once written, so a concurrent reconcile never loads it half written.
+	// CreateTemp makes it readable by the owner only, as libpq requires for private keys
+	tmp, err := os.CreateTemp(tlsDir, "*.tmp")
+	if err != nil {
+		return "", fmt.Errorf(`error creating file in %s : %w`, tlsDir, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf(`error writing file %s : %w`, tmp.Name(), err)
+	}
+	if err = tmp.Close(); err != nil {
+		return "", fmt.Errorf(`error writing file %s : %w`, tmp.Name(), err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf(`error writing file %s : %w`, path, err)
+	}
+	return path, nil
+}
+
+// tlsReferencesSecret tells whether the Secret with the given name holds certificates of the TLS settings
+func tlsReferencesSecret(tls *v1.TLSSpec, name string) bool {
+	if tls == nil {
+		return false
+	}
+	if tls.CA != nil && tls.CA.SecretKeyRef != nil && tls.CA.SecretKeyRef.Name == name {
+		return true
+	}
+	return tls.ClientCertSecretRef != nil && tls.ClientCertSecretRef.Name == name
+}
+
+// tlsReferencesConfigMap tells whether the ConfigMap with the given name holds the CA bundle of the TLS settings
+func tlsReferencesConfigMap(tls *v1.TLSSpec, name string) bool {
+	return tls != nil && tls.CA != nil && tls.CA.ConfigMapKeyRef != nil && tls.CA.ConfigMapKeyRef.Name == name
+}
+
+func validateTLS(tls *v1.TLSSpec) error {
+	if tls == nil {
+		return nil
+	}
+	switch tls.SSLMode {
+	case "", v1.SSLModeRequire, v1.SSLModeVerifyCA, v1.SSLModeVerifyFull:
+	case v1.SSLModeDisable:
+		if tls.CA != nil || tls.ClientCertSecretRef != nil {
+			return fmt.Errorf(`tls.ca and tls.clientCertSecretRef can not be used with sslMode disable`)
+		}
+	default:
+		return fmt.Errorf(`invalid sslMode %s`, tls.SSLMode)
+	}
+	if ca := tls.CA; ca != nil {
+		if (ca.SecretKeyRef == nil) == (ca.ConfigMapKeyRef == nil) {
+			return fmt.Errorf(`tls.ca requires exactly one of secretKeyRef and configMapKeyRef`)
+		}
+		if ca.SecretKeyRef != nil && ca.SecretKeyRef.Name == "" || ca.ConfigMapKeyRef != nil && ca.ConfigMapKeyRef.Name == "" {
+			return fmt.Errorf(`tls.ca name is required`)
+		}
+	}
+	if tls.ClientCertSecretRef != nil && tls.ClientCertSecretRef.Name == "" {
+		return fmt.Errorf(`tls.clientCertSecretRef.name is required`)
+	}
+	return nil
+}
+
+// readCredentials reads the admin user and password from the Secret keys referenced by ref
+func readCredentials(ctx context.Context, c client.Client, secretName types.NamespacedName, ref *v1.CredentialsSecretRef) (string, string, error) {
+	userKey, passwordKey := ref.UserKey, ref.PasswordKey
+	if userKey == "" {
+		userKey = "username"
+	}
+	if passwordKey == "" {
+		passwordKey = "password"
+	}
+	user, err := readSecretKey(ctx, c, secretName, userKey)
+	if err != nil {
+		return "", "", err
+	}
+	if !pgsql.ValidName(user) {
+		return "", "", fmt.Errorf(`invalid user %s in secret %s`, user, secretName.String())
+	}
+	password, err := readSecretKey(ctx, c, secretName, passwordKey)
+	if err != nil {
+		return "", "", err
+	}
+	return user, password, nil
+}
+
+// databaseAddress returns the address of the server hosting the database
+func databaseAddress(ctx context.Context, c client.Client, dbSpec *v1.PostgreSQLDatabaseSpec) (string, error) {
+	if dbSpec.ServerRef == "" {
+		return dbSpec.Address, nil
+	}
+	server := &v1.PostgreSQLServer{}
+	if err := c.Get(ctx, types.NamespacedName{Name: dbSpec.ServerRef}, server); err != nil {
+		return "", fmt.Errorf(`error reading PostgreSQLServer %s : %w`, dbSpec.ServerRef, err)
+	}
+	return server.Spec.Address, nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// PoolOptions sizes the connection pools opened by the ConnectionRegistry, zero values keep the database/sql defaults
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// ConnectionRegistry holds the connection pools shared by the reconcilers, keyed by server and database.
+// Pools are reference counted, so replacing or evicting a pool only closes it once every reconcile using it
+// released it.
+type ConnectionRegistry struct {
+	options             PoolOptions
+	healthCheckInterval time.Duration
+
+	mu    sync.Mutex
+	pools map[string]*connectionPool
+	// closed is set once the registry stopped, no pool is opened or handed out anymore
+	closed bool
+}
+
+type connectionPool struct {
+	db      *sql.DB
+	connStr string
+	refs    int
+	// evicted pools are no longer in the registry and are closed when the last reference is released
+	evicted bool
+}
+
+// NewConnectionRegistry returns an empty registry pinging its pools every healthCheckInterval once started,
+// a zero interval disables the health checks
+func NewConnectionRegistry(options PoolOptions, healthCheckInterval time.Duration) *ConnectionRegistry {
+	return &ConnectionRegistry{
+		options:             options,
+		healthCheckInterval: healthCheckInterval,
+		pools:               make(map[string]*connectionPool),
+	}
+}
+
+// Open makes key resolve to a pool connected with connStr, replacing the pool of key when connStr changed.
+// It fails once the registry is closed.
+func (r *ConnectionRegistry) Open(key string, connStr string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return fmt.Errorf(`connection registry is closed, unable to open %s`, key)
+	}
+	if p := r.pools[key]; p != nil {
+		if p.connStr == connStr {
+			return nil
+		}
+		r.evict(key, p)
+	}
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return err
+	}
+	db.SetMaxOpenConns(r.options.MaxOpenConns)
+	db.SetMaxIdleConns(r.options.MaxIdleConns)
+	db.SetConnMaxLifetime(r.options.ConnMaxLifetime)
+	r.pools[key] = &connectionPool{db: db, connStr: connStr}
+	return nil
+}
+
+// Acquire returns the pool of key and the function releasing it, which must be called once the caller is done
+// with the pool. ok is false when there is no such pool or the registry is closed.
+func (r *ConnectionRegistry) Acquire(key string) (db *sql.DB, release func(), ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.pools[key]
+	if p == nil || r.closed {
+		return nil, nil, false
+	}
+	p.refs++
+	var once sync.Once
+	return p.db, func() { once.Do(func() { r.release(p) }) }, true
+}
+
+// Ping checks the server behind the pool of key is reachable, there is nothing to check
+// when there is no such pool
+func (r *ConnectionRegistry) Ping(ctx context.Context, key string) error {
+	db, release, ok := r.Acquire(key)
+	if !ok {
+		return nil
+	}
+	defer release()
+	return db.PingContext(ctx)
+}
+
+// Evict removes the pool of key from the registry
+func (r *ConnectionRegistry) Evict(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p := r.pools[key]; p != nil {
+		r.evict(key, p)
+	}
+}
+
+// evict must be called holding the lock
+func (r *ConnectionRegistry) evict(key string, p *connectionPool) {
+	delete(r.pools, key)
+	p.evicted = true
+	if p.refs == 0 {
+		p.db.Close()
+	}
+}
+
+func (r *ConnectionRegistry) release(p *connectionPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.refs--
+	if p.evicted && p.refs == 0 {
+		p.db.Close()
+	}
+}
+
+// Start pings the pools until ctx is done and then closes them. database/sql drops the broken connections
+// found by the pings, so idle pools reconnect before a reconcile needs them.
+// It implements manager.Runnable.
+func (r *ConnectionRegistry) Start(ctx context.Context) error {
+	var tick <-chan time.Time
+	if r.healthCheckInterval > 0 {
+		ticker := time.NewTicker(r.healthCheckInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			r.closeAll()
+			return nil
+		case <-tick:
+			r.healthCheck(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection runs the registry on every replica, reconcilers only use it on the leader.
+// It implements manager.LeaderElectionRunnable.
+func (r *ConnectionRegistry) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *ConnectionRegistry) healthCheck(ctx context.Context) {
+	r.mu.Lock()
+	keys := make([]string, 0, len(r.pools))
+	for key := range r.pools {
+		keys = append(keys, key)
+	}
+	r.mu.Unlock()
+	for _, key := range keys {
+		pingCtx, cancel := context.WithTimeout(ctx, r.healthCheckInterval)
+		if err := r.Ping(pingCtx, key); err != nil {
+			log.FromContext(ctx).Error(err, "Connection health check failed", "connection", key)
+		}
+		cancel()
+	}
+}
+
+// closeAll closes the registry, the pools still acquired are closed when released
+func (r *ConnectionRegistry) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for key, p := range r.pools {
+		r.evict(key, p)
+	}
+}
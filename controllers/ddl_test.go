@@ -0,0 +1,306 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "database-account-operator/api/v1"
+)
+
+// recordingDriver is a database/sql driver recording the statements it is sent, so the DDL built by the
+// reconcilers can be checked without a server. The queries containing a key of results return its rows, the
+// others no rows.
+type recordingDriver struct {
+	mu         sync.Mutex
+	statements []string
+	results    map[string][][]driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) {
+	return &recordingConn{d}, nil
+}
+
+func (d *recordingDriver) take() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	statements := d.statements
+	d.statements = nil
+	return statements
+}
+
+// respond makes the queries containing query return rows
+func (d *recordingDriver) respond(query string, rows ...[]driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.results == nil {
+		d.results = map[string][][]driver.Value{}
+	}
+	d.results[query] = rows
+}
+
+func (d *recordingDriver) reset() {
+	d.take()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.results = nil
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.statements = append(c.driver.statements, query)
+	for key, rows := range c.driver.results {
+		if strings.Contains(query, key) {
+			return &cannedRows{rows: rows}, nil
+		}
+	}
+	return &cannedRows{}, nil
+}
+
+type cannedRows struct {
+	rows [][]driver.Value
+}
+
+func (r *cannedRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *cannedRows) Close() error { return nil }
+
+func (r *cannedRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var ddlRecorder = &recordingDriver{}
+
+func init() {
+	sql.Register("recording", ddlRecorder)
+}
+
+// openRecording returns a database recording its statements in ddlRecorder
+func openRecording(t *testing.T) *sql.DB {
+	db, err := sql.Open("recording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		ddlRecorder.reset()
+	})
+	return db
+}
+
+func checkStatement(t *testing.T, err error, want string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error : %v", err)
+	}
+	statements := ddlRecorder.take()
+	if len(statements) != 1 || statements[0] != want {
+		t.Fatalf("statements = %q, want [%q]", statements, want)
+	}
+}
+
+func TestCreateAccountDDL(t *testing.T) {
+	db := openRecording(t)
+	r := &PostgreSQLAccountReconciler{}
+	tests := []struct {
+		account v1.PostgreSQLAccountSpec
+		want    string
+	}{
+		{v1.PostgreSQLAccountSpec{Name: `app`}, `CREATE ROLE "app" WITH LOGIN PASSWORD NULL`},
+		{v1.PostgreSQLAccountSpec{Name: `a"b`}, `CREATE ROLE "a""b" WITH LOGIN PASSWORD NULL`},
+		{v1.PostgreSQLAccountSpec{Name: `x"; DROP ROLE x; --`}, `CREATE ROLE "x""; DROP ROLE x; --" WITH LOGIN PASSWORD NULL`},
+		// the server rejects the statement as holding an unterminated quoted identifier
+		{v1.PostgreSQLAccountSpec{Name: "a\x00b"}, "CREATE ROLE \"a\x00b\" WITH LOGIN PASSWORD NULL"},
+		{v1.PostgreSQLAccountSpec{Name: `app`, ValidUntil: `'; DROP ROLE x; --`},
+			`CREATE ROLE "app" WITH LOGIN VALID UNTIL '''; DROP ROLE x; --' PASSWORD NULL`},
+		{v1.PostgreSQLAccountSpec{Name: `app`, ValidUntil: `\'; DROP ROLE x; --`},
+			`CREATE ROLE "app" WITH LOGIN VALID UNTIL E'\\''; DROP ROLE x; --' PASSWORD NULL`},
+	}
+	for _, tt := range tests {
+		err := r.createAccount(db, &tt.account)
+		checkStatement(t, err, tt.want)
+	}
+
+	// the password is sent as its SCRAM-SHA-256 verifier, never in clear
+	password := `p'w\"; DROP ROLE x; --`
+	if err := r.createAccount(db, &v1.PostgreSQLAccountSpec{Name: `app`, Password: password}); err != nil {
+		t.Fatal(err)
+	}
+	statements := ddlRecorder.take()
+	if len(statements) != 1 || !strings.HasPrefix(statements[0], `CREATE ROLE "app" WITH LOGIN PASSWORD 'SCRAM-SHA-256$4096:`) {
+		t.Fatalf("statements = %q, want a SCRAM-SHA-256 password", statements)
+	}
+	if strings.Contains(statements[0], password) {
+		t.Fatalf("statement %q holds the password", statements[0])
+	}
+}
+
+func TestCreateDBDDL(t *testing.T) {
+	db := openRecording(t)
+	r := &PostgreSQLDatabaseReconciler{}
+	tests := []struct {
+		database v1.PostgreSQLDatabaseSpec
+		want     string
+	}{
+		{v1.PostgreSQLDatabaseSpec{Database: `app`}, `CREATE DATABASE "app"`},
+		{v1.PostgreSQLDatabaseSpec{Database: `x"; DROP ROLE x; --`}, `CREATE DATABASE "x""; DROP ROLE x; --"`},
+		{v1.PostgreSQLDatabaseSpec{Database: `app`, Encoding: `UTF8`, LC_Collate: `C.UTF-8`, LC_CType: `en_US.utf8`},
+			`CREATE DATABASE "app" TEMPLATE template0 ENCODING 'UTF8' LC_COLLATE 'C.UTF-8' LC_CTYPE 'en_US.utf8'`},
+		{v1.PostgreSQLDatabaseSpec{Database: `app`, Encoding: `'; DROP ROLE x; --`},
+			`CREATE DATABASE "app" TEMPLATE template0 ENCODING '''; DROP ROLE x; --'`},
+		{v1.PostgreSQLDatabaseSpec{Database: `app`, LC_CType: `\'; DROP ROLE x; --`},
+			`CREATE DATABASE "app" TEMPLATE template0 LC_CTYPE E'\\''; DROP ROLE x; --'`},
+		{v1.PostgreSQLDatabaseSpec{Database: `app`, Encoding: "UTF8\x00"}, "CREATE DATABASE \"app\" TEMPLATE template0 ENCODING 'UTF8\x00'"},
+	}
+	for _, tt := range tests {
+		err := r.createDB(db, &tt.database)
+		checkStatement(t, err, tt.want)
+	}
+}
+
+func TestCreateSchemaDDL(t *testing.T) {
+	db := openRecording(t)
+	r := &PostgreSQLGrantReconciler{}
+	tests := []struct {
+		schema, want string
+	}{
+		{`Public`, `CREATE SCHEMA "public"`},
+		{`a"b`, `CREATE SCHEMA "a""b"`},
+		{`x"; drop role x; --`, `CREATE SCHEMA "x""; drop role x; --"`},
+	}
+	for _, tt := range tests {
+		err := r.createSchema(db, tt.schema)
+		checkStatement(t, err, tt.want)
+	}
+}
+
+func TestCreateGrantDDL(t *testing.T) {
+	db := openRecording(t)
+	r := &PostgreSQLGrantReconciler{}
+	tests := []struct {
+		grant      v1.PostgreSQLGrantSpec
+		privileges []string
+		want       string
+	}{
+		{v1.PostgreSQLGrantSpec{Schema: `app`, To: `reader`}, []string{"SELECT"},
+			`GRANT SELECT ON ALL TABLES IN SCHEMA "app" TO "reader"`},
+		{v1.PostgreSQLGrantSpec{Schema: `App`, To: `a"b`}, []string{"SELECT", "INSERT"},
+			`GRANT SELECT, INSERT ON ALL TABLES IN SCHEMA "app" TO "a""b"`},
+		{v1.PostgreSQLGrantSpec{Schema: `x"; drop role x; --`, To: `'; DROP ROLE x; --`}, []string{"ALL"},
+			`GRANT ALL ON ALL TABLES IN SCHEMA "x""; drop role x; --" TO "'; DROP ROLE x; --"`},
+	}
+	for _, tt := range tests {
+		err := r.createGrant(db, &tt.grant, tt.privileges)
+		checkStatement(t, err, tt.want)
+	}
+}
+
+// grantStatements returns the GRANT and REVOKE statements recorded, leaving out the queries reading the catalogs
+func grantStatements() []string {
+	var statements []string
+	for _, statement := range ddlRecorder.take() {
+		if strings.HasPrefix(statement, "GRANT") || strings.HasPrefix(statement, "REVOKE") {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+func TestUpsertGrantOwnedTables(t *testing.T) {
+	db := openRecording(t)
+	r := &PostgreSQLGrantReconciler{}
+	grant := &v1.PostgreSQLGrantSpec{Schema: `app`, To: `owner`, Type: []string{"select"}}
+	tests := []struct {
+		name string
+		// privilege, tables it is held on, tables it was granted on by another role
+		grants [][]driver.Value
+		want   []string
+	}{
+		{
+			name: "the grantee owns one of the two tables",
+			grants: [][]driver.Value{
+				{"SELECT", int64(2), int64(1)}, {"INSERT", int64(1), int64(0)}, {"UPDATE", int64(1), int64(0)},
+				{"DELETE", int64(1), int64(0)}, {"TRUNCATE", int64(1), int64(0)}, {"REFERENCES", int64(1), int64(0)},
+				{"TRIGGER", int64(1), int64(0)},
+			},
+		},
+		{
+			name: "the grantee owns both tables",
+			grants: [][]driver.Value{
+				{"SELECT", int64(2), int64(0)}, {"INSERT", int64(2), int64(0)}, {"UPDATE", int64(2), int64(0)},
+				{"DELETE", int64(2), int64(0)}, {"TRUNCATE", int64(2), int64(0)}, {"REFERENCES", int64(2), int64(0)},
+				{"TRIGGER", int64(2), int64(0)},
+			},
+		},
+		{
+			name: "the owner of a table was also granted INSERT on the other one",
+			grants: [][]driver.Value{
+				{"SELECT", int64(2), int64(1)}, {"INSERT", int64(2), int64(1)}, {"UPDATE", int64(1), int64(0)},
+			},
+			want: []string{`REVOKE INSERT ON ALL TABLES IN SCHEMA "app" FROM "owner"`},
+		},
+		{
+			name:   "the grantee owns no table and was granted nothing",
+			grants: nil,
+			want:   []string{`GRANT SELECT ON ALL TABLES IN SCHEMA "app" TO "owner"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddlRecorder.respond("information_schema.tables", []driver.Value{int64(2)})
+			ddlRecorder.respond("information_schema.role_table_grants", tt.grants...)
+			if _, err := r.upsertGrant(db, grant, nil); err != nil {
+				t.Fatal(err)
+			}
+			if statements := grantStatements(); strings.Join(statements, ";") != strings.Join(tt.want, ";") {
+				t.Fatalf("statements = %q, want %q", statements, tt.want)
+			}
+		})
+	}
+}
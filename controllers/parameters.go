@@ -0,0 +1,256 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"database-account-operator/pkg/pgsql"
+)
+
+// settingsScope is the database and role the parameters of pg_db_role_setting apply to,
+// an empty database or role meaning all of them
+type settingsScope struct {
+	database, role string
+}
+
+func (s settingsScope) String() string {
+	switch {
+	case s.database == "":
+		return "role " + s.role
+	case s.role == "":
+		return "database " + s.database
+	default:
+		return fmt.Sprintf("role %s in database %s", s.role, s.database)
+	}
+}
+
+// alter returns the ALTER statement the SET and RESET of the parameters of the scope are appended to
+func (s settingsScope) alter() string {
+	switch {
+	case s.database == "":
+		return `ALTER ROLE ` + pgsql.QuoteIdent(s.role)
+	case s.role == "":
+		return `ALTER DATABASE ` + pgsql.QuoteIdent(s.database)
+	default:
+		return fmt.Sprintf(`ALTER ROLE %s IN DATABASE %s`, pgsql.QuoteIdent(s.role), pgsql.QuoteIdent(s.database))
+	}
+}
+
+// listParameters are the parameters whose value is a list of possibly quoted names, which the server stores
+// quoted as identifiers. pg_dump keeps the same list.
+var listParameters = map[string]bool{
+	"local_preload_libraries":   true,
+	"search_path":               true,
+	"session_preload_libraries": true,
+	"shared_preload_libraries":  true,
+	"temp_tablespaces":          true,
+}
+
+var parameterName = regexp.MustCompile(`^[a-z_][a-z0-9_$]*(\.[a-z_][a-z0-9_$]*)*$`)
+
+func validateParameters(field string, parameters map[string]string) error {
+	for name, value := range parameters {
+		if !parameterName.MatchString(name) {
+			return fmt.Errorf(`invalid %s name %s`, field, name)
+		}
+		if listParameters[name] {
+			if elements, err := splitList(value); err != nil || len(elements) == 0 {
+				return fmt.Errorf(`invalid %s %s value %s, a comma separated list is expected`, field, name, value)
+			}
+		}
+	}
+	return nil
+}
+
+// upsertParameters sets and resets the parameters of the scope to match the desired ones, returning the drifts
+// it corrected, or only the differences when observe is set. Nil desired parameters are not managed, while
+// an empty map resets them all.
+func upsertParameters(db *sql.DB, scope settingsScope, desired map[string]string, observe bool) ([]string, error) {
+	if desired == nil {
+		return nil, nil
+	}
+	current, err := readParameters(db, scope)
+	if err != nil {
+		return nil, err
+	}
+	// the differences observed are reported as they are, the ones corrected as they were
+	verb := "was"
+	if observe {
+		verb = "is"
+	}
+	var drifts, statements []string
+	for _, name := range sortedNames(desired) {
+		value, ok := current[name]
+		if ok && parameterEqual(name, value, desired[name]) {
+			continue
+		}
+		if ok {
+			drifts = append(drifts, fmt.Sprintf(`%s parameter %s %s '%s' instead of '%s'`, scope, name, verb, value, desired[name]))
+		} else {
+			drifts = append(drifts, fmt.Sprintf(`%s parameter %s %s not set`, scope, name, verb))
+		}
+		statements = append(statements, fmt.Sprintf(`%s SET %s = %s`, scope.alter(), quoteParameterName(name), parameterValue(name, desired[name])))
+	}
+	for _, name := range sortedNames(current) {
+		if _, ok := desired[name]; !ok {
+			drifts = append(drifts, fmt.Sprintf(`%s parameter %s %s set to '%s'`, scope, name, verb, current[name]))
+			statements = append(statements, fmt.Sprintf(`%s RESET %s`, scope.alter(), quoteParameterName(name)))
+		}
+	}
+	if observe {
+		return drifts, nil
+	}
+	for _, statement := range statements {
+		if _, err = db.Exec(statement); err != nil {
+			return nil, fmt.Errorf(`error executing query %s for %s : %w`, statement, scope, err)
+		}
+	}
+	return drifts, nil
+}
+
+// readParameters returns the parameters stored for the scope in pg_db_role_setting by lowercased name, none when
+// its database or role does not exist
+func readParameters(db *sql.DB, scope settingsScope) (map[string]string, error) {
+	query := `SELECT unnest(s.setconfig) FROM pg_catalog.pg_db_role_setting s
+		WHERE s.setdatabase = CASE WHEN $1::text = '' THEN 0 ELSE (SELECT oid FROM pg_catalog.pg_database WHERE datname = $1::text) END
+		AND s.setrole = CASE WHEN $2::text = '' THEN 0 ELSE (SELECT oid FROM pg_catalog.pg_roles WHERE rolname = $2::text) END`
+	rows, err := db.Query(query, scope.database, scope.role)
+	if err != nil {
+		return nil, fmt.Errorf(`error executing query %s for %s : %w`, query, scope, err)
+	}
+	defer rows.Close()
+	parameters := map[string]string{}
+	for rows.Next() {
+		var setting string
+		if err = rows.Scan(&setting); err != nil {
+			return nil, fmt.Errorf(`error reading parameters of %s : %w`, scope, err)
+		}
+		// the names of the built-in parameters are stored with their own case, like DateStyle
+		if i := strings.IndexByte(setting, '='); i > 0 {
+			parameters[strings.ToLower(setting[:i])] = setting[i+1:]
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf(`error iterating parameters of %s : %w`, scope, err)
+	}
+	return parameters, nil
+}
+
+func parameterEqual(name, current, desired string) bool {
+	if !listParameters[name] {
+		return current == desired
+	}
+	currentElements, err := splitList(current)
+	if err != nil {
+		return false
+	}
+	desiredElements, err := splitList(desired)
+	if err != nil || len(currentElements) != len(desiredElements) {
+		return false
+	}
+	for i := range currentElements {
+		if currentElements[i] != desiredElements[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parameterValue returns the value of the SET of the parameter, sending the elements of the list parameters
+// one by one so the server quotes them
+func parameterValue(name, value string) string {
+	if !listParameters[name] {
+		return pgsql.QuoteLiteral(value)
+	}
+	elements, _ := splitList(value)
+	for i := range elements {
+		elements[i] = pgsql.QuoteLiteral(elements[i])
+	}
+	return strings.Join(elements, ", ")
+}
+
+func quoteParameterName(name string) string {
+	parts := strings.Split(name, ".")
+	for i := range parts {
+		parts[i] = pgsql.QuoteIdent(parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// splitList splits a comma separated list of names the way the server does, unquoting the double quoted names
+// and lowercasing the others
+func splitList(value string) ([]string, error) {
+	var elements []string
+	rest := strings.TrimSpace(value)
+	for rest != "" {
+		var element string
+		if rest[0] == '"' {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest); i++ {
+				if rest[i] == '"' {
+					if i+1 < len(rest) && rest[i+1] == '"' {
+						b.WriteByte('"')
+						i++
+						continue
+					}
+					break
+				}
+				b.WriteByte(rest[i])
+			}
+			if i == len(rest) {
+				return nil, fmt.Errorf(`unterminated quoted name in %s`, value)
+			}
+			element, rest = b.String(), strings.TrimSpace(rest[i+1:])
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			element, rest = strings.ToLower(strings.TrimSpace(rest[:end])), rest[end:]
+			if element == "" {
+				return nil, fmt.Errorf(`empty name in %s`, value)
+			}
+		}
+		elements = append(elements, element)
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf(`expected a comma after %s in %s`, element, value)
+		}
+		rest = strings.TrimSpace(rest[1:])
+		if rest == "" {
+			return nil, fmt.Errorf(`trailing comma in %s`, value)
+		}
+	}
+	return elements, nil
+}
+
+func sortedNames(parameters map[string]string) []string {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
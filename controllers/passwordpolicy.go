@@ -0,0 +1,161 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "database-account-operator/api/v1"
+	"database-account-operator/pkg/scram"
+)
+
+// passwordPolicies returns the PostgreSQLPasswordPolicies applying to the PostgreSQLAccounts of the namespace
+func passwordPolicies(ctx context.Context, c client.Reader, namespace string) ([]v1.PostgreSQLPasswordPolicy, error) {
+	policyList := &v1.PostgreSQLPasswordPolicyList{}
+	if err := c.List(ctx, policyList); err != nil {
+		return nil, fmt.Errorf(`error listing PostgreSQLPasswordPolicies : %w`, err)
+	}
+	var policies []v1.PostgreSQLPasswordPolicy
+	for _, policy := range policyList.Items {
+		if policyApplies(&policy, namespace) {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+func policyApplies(policy *v1.PostgreSQLPasswordPolicy, namespace string) bool {
+	return len(policy.Spec.Namespaces) == 0 || containsString(policy.Spec.Namespaces, namespace)
+}
+
+// enforcePasswordPolicies checks the plain text password and the valid_until of the account against the policies
+func enforcePasswordPolicies(spec *v1.PostgreSQLAccountSpec, policies []v1.PostgreSQLPasswordPolicy) error {
+	if err := validateValidUntil(spec.ValidUntil, policies, time.Now()); err != nil {
+		return err
+	}
+	if spec.Password == "" {
+		return nil
+	}
+	return validatePassword(spec.Password, policies)
+}
+
+// checkPasswordPolicies enforces the policies on the password and the valid_until of the account only once they
+// change, like the webhook does on updates, so a new policy applies to the existing accounts as they rotate their
+// password. The ones checked last are recorded in the status, the password as its SCRAM-SHA-256 verifier.
+func checkPasswordPolicies(spec *v1.PostgreSQLAccountSpec, password string, policies []v1.PostgreSQLPasswordPolicy, status *v1.PostgreSQLAccountStatus) error {
+	if checked := status.PasswordPolicyCheck; checked != nil && checked.ValidUntil == spec.ValidUntil &&
+		passwordMatches(checked.PasswordVerifier, password) {
+		return nil
+	}
+	if err := validateValidUntil(spec.ValidUntil, policies, time.Now()); err != nil {
+		return err
+	}
+	var verifier string
+	if password != "" {
+		if err := validatePassword(password, policies); err != nil {
+			return err
+		}
+		var err error
+		if verifier, err = scram.Verifier(password, scram.DefaultIterations); err != nil {
+			return fmt.Errorf(`error hashing password for account %s : %w`, spec.Name, err)
+		}
+	}
+	status.PasswordPolicyCheck = &v1.PasswordPolicyCheck{PasswordVerifier: verifier, ValidUntil: spec.ValidUntil}
+	return nil
+}
+
+// checkedPassword returns the password of the resolved spec the policies are enforced on, the generated passwords
+// being left out
+func checkedPassword(resolved *v1.PostgreSQLAccountSpec) string {
+	if resolved.GeneratePassword {
+		return ""
+	}
+	return resolved.Password
+}
+
+// validatePassword returns an error listing every rule of the policies the password breaks
+func validatePassword(password string, policies []v1.PostgreSQLPasswordPolicy) error {
+	var upper, lower, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		case !unicode.IsLetter(c):
+			symbol = true
+		}
+	}
+	for _, policy := range policies {
+		spec := &policy.Spec
+		var violations []string
+		if n := utf8.RuneCountInString(password); n < int(spec.MinLength) {
+			violations = append(violations, fmt.Sprintf(`it has %d characters instead of at least %d`, n, spec.MinLength))
+		}
+		for _, class := range []struct {
+			required, found bool
+			name            string
+		}{
+			{spec.RequireUppercase, upper, "uppercase letter"},
+			{spec.RequireLowercase, lower, "lowercase letter"},
+			{spec.RequireDigit, digit, "digit"},
+			{spec.RequireSymbol, symbol, "symbol"},
+		} {
+			if class.required && !class.found {
+				violations = append(violations, "it contains no "+class.name)
+			}
+		}
+		for _, forbidden := range spec.ForbiddenPasswords {
+			if strings.EqualFold(password, forbidden) {
+				violations = append(violations, "it is a forbidden password")
+				break
+			}
+		}
+		if len(violations) > 0 {
+			return fmt.Errorf(`password rejected by PostgreSQLPasswordPolicy %s : %s`, policy.Name, strings.Join(violations, ", "))
+		}
+	}
+	return nil
+}
+
+// validateValidUntil checks the expiration of the role is set within the horizon of the policies
+func validateValidUntil(validUntil string, policies []v1.PostgreSQLPasswordPolicy, now time.Time) error {
+	for _, policy := range policies {
+		days := policy.Spec.MaxValidUntilDays
+		if days == nil {
+			continue
+		}
+		if validUntil == "" {
+			return fmt.Errorf(`valid_until is required by PostgreSQLPasswordPolicy %s, at most %d days from now`, policy.Name, *days)
+		}
+		limit := now.AddDate(0, 0, int(*days))
+		if date, err := time.Parse("2006-01-02", validUntil); err == nil && date.After(limit) {
+			return fmt.Errorf(`valid_until %s rejected by PostgreSQLPasswordPolicy %s : it must not be after %s, %d days from now`,
+				validUntil, policy.Name, limit.Format("2006-01-02"), *days)
+		}
+	}
+	return nil
+}
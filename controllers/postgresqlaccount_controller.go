@@ -17,38 +17,160 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 
+	"github.com/lib/pq"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	v1 "database-account-operator/api/v1"
+	"database-account-operator/pkg/pgsql"
+	"database-account-operator/pkg/scram"
 )
 
 // PostgreSQLAccountReconciler reconciles a PostgreSQLAccount object
 type PostgreSQLAccountReconciler struct {
 	client.Client
-	Scheme          *runtime.Scheme
-	DBClients       *map[string]*sql.DB
-	previousAccount *v1.PostgreSQLAccountSpec
+	Scheme      *runtime.Scheme
+	Connections *ConnectionRegistry
+	Recorder    record.EventRecorder
+	// ResyncInterval is how often the accounts are checked for drift, zero disabling the checks
+	ResyncInterval time.Duration
+	// ScramIterations is the iteration count of the SCRAM-SHA-256 verifiers of the passwords,
+	// zero meaning scram.DefaultIterations
+	ScramIterations   int
+	dependencyBackoff workqueue.RateLimiter
 }
 
+// memberOfField indexes the PostgreSQLAccounts by the roles they are members of
+const memberOfField = ".spec.memberOf.role"
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *PostgreSQLAccountReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.dependencyBackoff = newDependencyBackoff()
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1.PostgreSQLAccount{}, databaseNameField, func(object client.Object) []string {
+		return []string{object.(*v1.PostgreSQLAccount).Spec.PostgreSQLDatabaseName}
+	}); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1.PostgreSQLAccount{}, memberOfField, func(object client.Object) []string {
+		var roles []string
+		for _, membership := range object.(*v1.PostgreSQLAccount).Spec.MemberOf {
+			roles = append(roles, membership.Role)
+		}
+		return roles
+	}); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1.PostgreSQLAccount{}).
+		For(&v1.PostgreSQLAccount{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&corev1.Secret{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findAccountsForSecret)).
+		Watches(&source.Kind{Type: &v1.PostgreSQLDatabase{}}, handler.EnqueueRequestsFromMapFunc(r.findAccountsForDatabase),
+			builder.WithPredicates(becameReady)).
+		Watches(&source.Kind{Type: &v1.PostgreSQLAccount{}}, handler.EnqueueRequestsFromMapFunc(r.findMembersOfAccount),
+			builder.WithPredicates(becameReady)).
+		Watches(&source.Kind{Type: &v1.PostgreSQLPasswordPolicy{}}, handler.EnqueueRequestsFromMapFunc(r.findAccountsForPolicy)).
 		Complete(r)
 }
 
+// findAccountsForPolicy maps a PostgreSQLPasswordPolicy to the PostgreSQLAccounts it applies to,
+// so they are validated again against it
+func (r *PostgreSQLAccountReconciler) findAccountsForPolicy(policy client.Object) []reconcile.Request {
+	accountList := &v1.PostgreSQLAccountList{}
+	if err := r.List(context.Background(), accountList); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, account := range accountList.Items {
+		if policyApplies(policy.(*v1.PostgreSQLPasswordPolicy), account.Namespace) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: account.Name, Namespace: account.Namespace}})
+		}
+	}
+	return requests
+}
+
+// findMembersOfAccount maps a PostgreSQLAccount to the PostgreSQLAccounts that are members of its role,
+// so they are retried once the role is ready
+func (r *PostgreSQLAccountReconciler) findMembersOfAccount(group client.Object) []reconcile.Request {
+	accountList := &v1.PostgreSQLAccountList{}
+	if err := r.List(context.Background(), accountList, client.InNamespace(group.GetNamespace()),
+		client.MatchingFields{memberOfField: group.(*v1.PostgreSQLAccount).Spec.Name}); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, account := range accountList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: account.Name, Namespace: account.Namespace}})
+	}
+	return requests
+}
+
+// findAccountsForDatabase maps a PostgreSQLDatabase to the PostgreSQLAccounts referencing it,
+// so they are retried once the database is ready
+func (r *PostgreSQLAccountReconciler) findAccountsForDatabase(db client.Object) []reconcile.Request {
+	accountList := &v1.PostgreSQLAccountList{}
+	if err := r.List(context.Background(), accountList, client.InNamespace(db.GetNamespace()),
+		client.MatchingFields{databaseNameField: db.GetName()}); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, account := range accountList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: account.Name, Namespace: account.Namespace}})
+	}
+	return requests
+}
+
+// findAccountsForSecret maps a Secret to the PostgreSQLAccounts reading their password from it
+func (r *PostgreSQLAccountReconciler) findAccountsForSecret(secret client.Object) []reconcile.Request {
+	accountList := &v1.PostgreSQLAccountList{}
+	if err := r.List(context.Background(), accountList, client.InNamespace(secret.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range accountList.Items {
+		account := &accountList.Items[i]
+		if name, _ := passwordSecret(account); name == secret.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: account.Name, Namespace: account.Namespace}})
+		}
+	}
+	return requests
+}
+
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlaccounts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlaccounts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlaccounts/finalizers,verbs=update
+//+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqldatabases,verbs=get;list;watch
+//+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlpasswordpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -60,66 +182,596 @@ func (r *PostgreSQLAccountReconciler) Reconcile(ctx context.Context, req ctrl.Re
 
 	accountApiResource := &v1.PostgreSQLAccount{}
 
-	r.Get(ctx, req.NamespacedName, accountApiResource)
+	if err := r.Get(ctx, req.NamespacedName, accountApiResource); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !accountApiResource.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalizeAccount(ctx, accountApiResource)
+	}
+	if !controllerutil.ContainsFinalizer(accountApiResource, finalizerName) {
+		// patched so the fields the spec omits when encoded, like empty parameters, are kept
+		patch := client.MergeFrom(accountApiResource.DeepCopy())
+		controllerutil.AddFinalizer(accountApiResource, finalizerName)
+		if err := r.Patch(ctx, accountApiResource, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
 	accountSpec := accountApiResource.Spec
-	accountStatus := accountApiResource.Status
 	dbNamespacedName := types.NamespacedName{Name: accountSpec.PostgreSQLDatabaseName, Namespace: req.Namespace}
+	wasInSync := inSync(&accountApiResource.Status.ReconcileStatus, accountApiResource.Generation)
 
 	var e error
-	if err := validateAccount(&accountSpec); err != nil {
+	var drifts []string
+	// suspended tells whether a disabled account was locked, which may happen before the reconcile fails
+	suspended := false
+	db, release, dbErr := acquireDatabase(ctx, r.Client, r.Connections, &dbNamespacedName)
+	if dbErr == nil {
+		defer release()
+	}
+	var policies []v1.PostgreSQLPasswordPolicy
+	var err error
+	// a disabled account is locked ahead of the validation, so neither an invalid spec nor a password rejected
+	// by the policies keep its sessions open
+	if dbErr == nil && !observed(accountSpec.ManagementPolicy) {
+		err = r.suspendAccount(db, accountApiResource, &drifts, &suspended)
+	}
+	if err != nil {
+		e = err
+	} else if policies, err = passwordPolicies(ctx, r.Client, req.Namespace); err != nil {
 		e = err
-	} else if (*r.DBClients)[dbNamespacedName.String()] == nil {
-		e = fmt.Errorf("unable to find db client for PostgreSQLDatabase, is there a PostgreSQLDatabase api resource with name %s in ready status?", dbNamespacedName.String())
-	} else if err = r.upsertAccount(&dbNamespacedName, &accountSpec); err != nil {
+	} else if err = validateAccount(&accountSpec); err != nil {
+		e = invalidSpecError(err)
+	} else if dbErr != nil {
+		e = connectionError(dbErr)
+	} else if observed(accountSpec.ManagementPolicy) {
+		// an observed role keeps its password, so neither the password nor the connection Secret are managed
+		if err = checkPasswordPolicies(&accountSpec, accountSpec.Password, policies, &accountApiResource.Status); err != nil {
+			e = invalidSpecError(err)
+		} else if e = r.upsertAccount(ctx, db, accountApiResource, &accountSpec, &drifts); e == nil {
+			if e = r.upsertMemberships(db, accountApiResource, &drifts); e == nil {
+				e = r.upsertAccountParameters(db, &accountSpec, &drifts)
+			}
+		}
+	} else if resolvedSpec, err := r.resolvePassword(ctx, accountApiResource); err != nil {
 		e = err
-	} else {
-		r.previousAccount = &accountSpec
+	} else if err = checkPasswordPolicies(&accountSpec, checkedPassword(resolvedSpec), policies, &accountApiResource.Status); err != nil {
+		e = invalidSpecError(err)
+	} else if err = r.upsertAccount(ctx, db, accountApiResource, resolvedSpec, &drifts); err != nil {
+		e = err
+	} else if err = r.upsertMemberships(db, accountApiResource, &drifts); err != nil {
+		e = err
+	} else if err = r.upsertAccountParameters(db, resolvedSpec, &drifts); err != nil {
+		e = err
+	} else if err = r.publishConnectionSecret(ctx, accountApiResource, &dbNamespacedName, resolvedSpec); err != nil {
+		e = err
+	}
+	setReconcileStatus(&accountApiResource.Status.ReconcileStatus, accountApiResource.Generation, e)
+	if e == nil {
+		if !wasInSync && !observed(accountSpec.ManagementPolicy) {
+			drifts = nil
+		}
+		recordDrift(r.Recorder, accountApiResource, &accountApiResource.Status.ReconcileStatus, drifts, observed(accountSpec.ManagementPolicy))
+	}
+	if !observed(accountSpec.ManagementPolicy) && (suspended || e == nil) {
+		setSuspendedCondition(accountApiResource)
+	}
+	if err := r.Status().Update(ctx, accountApiResource); err != nil {
+		return ctrl.Result{}, err
+	}
+	l.Info("Reconciled", "req", req, "account", accountSpec, "status", accountApiResource.Status)
+
+	return reconcileResult(r.dependencyBackoff, r.ResyncInterval, req, e)
+}
+
+// suspendAccount removes the LOGIN attribute of a disabled role and terminates its sessions. It runs ahead of the
+// validation of the spec, so the account is locked even when its spec is invalid or its password can not be resolved.
+func (r *PostgreSQLAccountReconciler) suspendAccount(db *sql.DB, account *v1.PostgreSQLAccount, drifts *[]string, suspended *bool) error {
+	spec := &account.Spec
+	if !spec.Disabled {
+		return nil
+	}
+	current, err := r.readRole(db, spec)
+	if err != nil || current == nil {
+		return err
+	}
+	if current.login {
+		*drifts = append(*drifts, fmt.Sprintf(`role %s was LOGIN instead of NOLOGIN`, spec.Name))
+		if err = r.alterAccount(db, spec, []string{`NOLOGIN`}); err != nil {
+			return err
+		}
 	}
-	accountStatus.Ready = e == nil
-	if e != nil {
-		accountStatus.Error = e.Error()
-	} else {
-		accountStatus.Error = ""
+	query := `SELECT COUNT(*) FILTER (WHERE pg_terminate_backend(pid)) FROM pg_catalog.pg_stat_activity
+		WHERE usename = $1 AND pid <> pg_backend_pid()`
+	var terminated int
+	if err = db.QueryRow(query, spec.Name).Scan(&terminated); err != nil {
+		return fmt.Errorf(`error executing query %s for account %s : %w`, query, spec.Name, err)
 	}
-	r.Status().Update(ctx, accountApiResource)
-	l.Info("Reconciled", "req", req, "account", accountSpec, "status", accountStatus)
+	if terminated > 0 {
+		r.Recorder.Eventf(account, corev1.EventTypeNormal, reasonSessionsTerminated, `terminated %d sessions of disabled role %s`, terminated, spec.Name)
+	}
+	*suspended = true
+	return nil
+}
 
-	return ctrl.Result{}, e
+// setSuspendedCondition records whether the account is disabled, the transition time of the condition telling
+// since when
+func setSuspendedCondition(account *v1.PostgreSQLAccount) {
+	condition := metav1.Condition{
+		Type:               v1.ConditionSuspended,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: account.Generation,
+		Reason:             reasonAccountEnabled,
+	}
+	if account.Spec.Disabled {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = reasonAccountDisabled
+		condition.Message = fmt.Sprintf(`role %s can not log in and its sessions are terminated`, account.Spec.Name)
+	}
+	meta.SetStatusCondition(&account.Status.Conditions, condition)
 }
 
-func (r *PostgreSQLAccountReconciler) upsertAccount(namespacedName *types.NamespacedName, account *v1.PostgreSQLAccountSpec) error {
-	validUntil, err := r.readValidUntil(namespacedName, account)
+// resolvePassword returns a copy of the account spec whose Password is read from a Secret when one is configured,
+// generating the Secret first if GeneratePassword is set and it does not exist yet
+func (r *PostgreSQLAccountReconciler) resolvePassword(ctx context.Context, account *v1.PostgreSQLAccount) (*v1.PostgreSQLAccountSpec, error) {
+	resolved := account.Spec
+	name, key := passwordSecret(account)
+	if name == "" {
+		return &resolved, nil
+	}
+	secretName := types.NamespacedName{Name: name, Namespace: account.Namespace}
+	if account.Spec.GeneratePassword {
+		if err := r.ensureGeneratedPassword(ctx, account, secretName, key); err != nil {
+			return nil, err
+		}
+	}
+	password, err := readSecretKey(ctx, r.Client, secretName, key)
 	if err != nil {
+		return nil, err
+	}
+	resolved.Password = password
+	return &resolved, nil
+}
+
+// passwordSecret returns the name and key of the Secret holding the account password, name is empty when the
+// password is set in plain text
+func passwordSecret(account *v1.PostgreSQLAccount) (string, string) {
+	if ref := account.Spec.PasswordSecretRef; ref != nil {
+		if ref.Key == "" {
+			return ref.Name, "password"
+		}
+		return ref.Name, ref.Key
+	}
+	if account.Spec.GeneratePassword {
+		return account.Name + "-password", "password"
+	}
+	return "", ""
+}
+
+func (r *PostgreSQLAccountReconciler) ensureGeneratedPassword(ctx context.Context, account *v1.PostgreSQLAccount, secretName types.NamespacedName, key string) error {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, secretName, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf(`error reading secret %s : %w`, secretName.String(), err)
+	}
+	exists := err == nil
+	if exists {
+		if _, ok := secret.Data[key]; ok {
+			return nil
+		}
+		if !metav1.IsControlledBy(secret, account) {
+			return fmt.Errorf(`secret %s has no key %s and is not owned by account %s`, secretName.String(), key, account.Name)
+		}
+	}
+	password, err := generatePassword()
+	if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[key] = []byte(password)
+	if exists {
+		return r.Update(ctx, secret)
+	}
+	secret.Name = secretName.Name
+	secret.Namespace = secretName.Namespace
+	if err := ctrl.SetControllerReference(account, secret, r.Scheme); err != nil {
 		return err
 	}
-	if validUntil == nil {
-		return r.createAccount(namespacedName, account)
+	return r.Create(ctx, secret)
+}
+
+const generatedPasswordLength = 32
+
+const passwordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func generatePassword() (string, error) {
+	password := make([]byte, generatedPasswordLength)
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf(`error generating password : %w`, err)
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// finalizerName is the finalizer set on the api resources to clean up the PostgreSQL objects on deletion
+const finalizerName = "database-account-operator.my.domain/finalizer"
+
+// createdAnnotation marks the api resources whose role or database the operator created, the only ones dropped on
+// deletion. It is set before creating them and kept in the metadata, as the status is lost on backup and restore.
+const createdAnnotation = "database-account-operator.my.domain/created"
+
+// markCreated sets createdAnnotation on the api resource, ahead of the creation of its role or database. A copy
+// is patched, so the status of the api resource recorded so far and the fields the spec omits when encoded, like
+// empty parameters, are kept.
+func markCreated(ctx context.Context, c client.Client, obj client.Object) error {
+	if created(obj) {
+		return nil
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotated := obj.DeepCopyObject().(client.Object)
+	annotations := annotated.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
 	}
-	if validUntil != &account.ValidUntil || r.previousAccount.Password != account.Password {
-		return r.updateAccount(namespacedName, account)
+	annotations[createdAnnotation] = "true"
+	annotated.SetAnnotations(annotations)
+	if err := c.Patch(ctx, annotated, patch); err != nil {
+		return fmt.Errorf(`error annotating %s before creating it : %w`, obj.GetName(), err)
 	}
+	obj.SetAnnotations(annotated.GetAnnotations())
+	obj.SetResourceVersion(annotated.GetResourceVersion())
 	return nil
 }
 
-func (r *PostgreSQLAccountReconciler) updateAccount(namespacedName *types.NamespacedName, account *v1.PostgreSQLAccountSpec) error {
-	query := fmt.Sprintf(`ALTER USER %s WITH PASSWORD '%s'`, account.Name, account.Password)
+// created tells whether the operator created the role or database of the api resource
+func created(obj client.Object) bool {
+	return obj.GetAnnotations()[createdAnnotation] == "true"
+}
 
-	if account.ValidUntil != "" {
-		query = fmt.Sprintf("%s VALID UNTIL '%s'", query, account.ValidUntil)
+// finalizeAccount drops the role it created unless the deletion policy retains it and then releases the finalizer
+func (r *PostgreSQLAccountReconciler) finalizeAccount(ctx context.Context, account *v1.PostgreSQLAccount) error {
+	if !controllerutil.ContainsFinalizer(account, finalizerName) {
+		return nil
 	}
+	// an invalid name was never created, and a role the operator adopted instead of creating it is kept
+	if account.Spec.DeletionPolicy != v1.DeletionPolicyRetain && !observed(account.Spec.ManagementPolicy) &&
+		created(account) && pgsql.ValidName(account.Spec.Name) {
+		dbNamespacedName := types.NamespacedName{Name: account.Spec.PostgreSQLDatabaseName, Namespace: account.Namespace}
+		db, release, err := acquireDatabase(ctx, r.Client, r.Connections, &dbNamespacedName)
+		if err != nil {
+			return err
+		}
+		err = r.dropAccount(db, &account.Spec)
+		release()
+		if err != nil {
+			return err
+		}
+	}
+	controllerutil.RemoveFinalizer(account, finalizerName)
+	return r.Update(ctx, account)
+}
 
-	rows, err := (*r.DBClients)[namespacedName.String()].Query(query)
+// dropAccount hands the objects owned by the role over to its successor and drops it.
+// REASSIGN OWNED and DROP OWNED only act on the database the connection points to.
+func (r *PostgreSQLAccountReconciler) dropAccount(db *sql.DB, account *v1.PostgreSQLAccountSpec) error {
+	exists, err := roleExists(db, account.Name)
+	if err != nil || !exists {
+		return err
+	}
+	successor := "CURRENT_USER"
+	if account.ReassignOwnedTo != "" {
+		successor = pgsql.QuoteIdent(account.ReassignOwnedTo)
+	}
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf(`error executing query ALTER USER ... for account %s : %w`, account.Name, err)
+		return fmt.Errorf(`error starting transaction to drop account %s : %w`, account.Name, err)
+	}
+	defer tx.Rollback()
+	for _, query := range []string{
+		fmt.Sprintf(`REASSIGN OWNED BY %s TO %s`, pgsql.QuoteIdent(account.Name), successor),
+		fmt.Sprintf(`DROP OWNED BY %s`, pgsql.QuoteIdent(account.Name)),
+		fmt.Sprintf(`DROP ROLE %s`, pgsql.QuoteIdent(account.Name)),
+	} {
+		if _, err = tx.Exec(query); err != nil {
+			return fmt.Errorf(`error executing query %s for account %s : %w`, query, account.Name, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf(`error committing drop of account %s : %w`, account.Name, err)
+	}
+	return nil
+}
+
+func roleExists(db *sql.DB, role string) (bool, error) {
+	query := `SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = $1`
+	rows, err := db.Query(query, role)
+	if err != nil {
+		return false, fmt.Errorf(`error executing query %s for role %s : %w`, query, role, err)
+	}
+	defer rows.Close()
+	return rows.Next(), rows.Err()
+}
+
+// connectionDetails are the values available to the connection Secret templates
+type connectionDetails struct {
+	Host, Port, Database, Username, Password, URI, JDBCURL string
+}
+
+var defaultConnectionSecretKeys = map[string]string{
+	"host":     "{{ .Host }}",
+	"port":     "{{ .Port }}",
+	"database": "{{ .Database }}",
+	"username": "{{ .Username }}",
+	"password": "{{ .Password }}",
+	"uri":      "{{ .URI }}",
+	"jdbc-url": "{{ .JDBCURL }}",
+}
+
+// publishConnectionSecret writes the owned Secret with the connection details of the account,
+// built from the server address and Database of the referenced PostgreSQLDatabase
+func (r *PostgreSQLAccountReconciler) publishConnectionSecret(ctx context.Context, account *v1.PostgreSQLAccount, dbNamespacedName *types.NamespacedName, accountSpec *v1.PostgreSQLAccountSpec) error {
+	db := &v1.PostgreSQLDatabase{}
+	if err := r.Get(ctx, *dbNamespacedName, db); err != nil {
+		return fmt.Errorf(`error reading PostgreSQLDatabase %s : %w`, dbNamespacedName.String(), err)
+	}
+	address, err := databaseAddress(ctx, r.Client, &db.Spec)
+	if err != nil {
+		return err
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf(`invalid address %s : %w`, address, err)
+	}
+	details := connectionDetails{
+		Host:     host,
+		Port:     port,
+		Database: db.Spec.Database,
+		Username: accountSpec.Name,
+		Password: accountSpec.Password,
+		URI: (&url.URL{
+			Scheme: "postgresql",
+			User:   url.UserPassword(accountSpec.Name, accountSpec.Password),
+			Host:   address,
+			Path:   "/" + db.Spec.Database,
+		}).String(),
+		JDBCURL: fmt.Sprintf("jdbc:postgresql://%s/%s?%s", address, url.PathEscape(db.Spec.Database),
+			url.Values{"user": {accountSpec.Name}, "password": {accountSpec.Password}}.Encode()),
+	}
+	keys := defaultConnectionSecretKeys
+	name := account.Name + "-connection"
+	if cs := accountSpec.ConnectionSecret; cs != nil {
+		if len(cs.Keys) > 0 {
+			keys = cs.Keys
+		}
+		if cs.Name != "" {
+			name = cs.Name
+		}
+	}
+	data := make(map[string][]byte, len(keys))
+	for key, text := range keys {
+		value, err := renderConnectionTemplate(key, text, &details)
+		if err != nil {
+			return err
+		}
+		data[key] = value
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: account.Namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.ResourceVersion != "" && !metav1.IsControlledBy(secret, account) {
+			return fmt.Errorf(`connection secret %s already exists and is not owned by account %s`, name, account.Name)
+		}
+		secret.Data = data
+		return ctrl.SetControllerReference(account, secret, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf(`error writing connection secret %s : %w`, name, err)
+	}
+	return nil
+}
+
+func renderConnectionTemplate(key, text string, details *connectionDetails) ([]byte, error) {
+	tmpl, err := template.New(key).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid template for connection secret key %s : %w`, key, err)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, details); err != nil {
+		return nil, fmt.Errorf(`error rendering connection secret key %s : %w`, key, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// upsertAccount creates or updates the role, appending to drifts the ones it had to correct to match the spec, or
+// only the differences with the spec when the role is observed. The api resource is marked as created before the
+// role is created.
+func (r *PostgreSQLAccountReconciler) upsertAccount(ctx context.Context, db *sql.DB, accountApiResource *v1.PostgreSQLAccount, account *v1.PostgreSQLAccountSpec, drifts *[]string) error {
+	current, err := r.readRole(db, account)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		if observed(account.ManagementPolicy) {
+			*drifts = append(*drifts, fmt.Sprintf(`role %s does not exist`, account.Name))
+			return nil
+		}
+		if err = markCreated(ctx, r.Client, accountApiResource); err != nil {
+			return err
+		}
+		if err = r.createAccount(db, account); err != nil {
+			return err
+		}
+		*drifts = append(*drifts, fmt.Sprintf(`role %s did not exist`, account.Name))
+		return nil
+	}
+	options, roleDrifts := roleOptions(current, desiredRoleAttributes(account), account.Name)
+	if observed(account.ManagementPolicy) {
+		*drifts = append(*drifts, roleDrifts...)
+		return nil
+	}
+	verifier, known, err := r.readPasswordVerifier(db, account)
+	if err != nil {
+		return err
+	}
+	// a password that can not be compared is set again
+	if !known || !passwordMatches(verifier, account.Password) {
+		if known {
+			roleDrifts = append(roleDrifts, fmt.Sprintf(`role %s password did not match`, account.Name))
+		}
+		password, err := r.passwordClause(account)
+		if err != nil {
+			return err
+		}
+		options = append(options, password)
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	if err = r.alterAccount(db, account, options); err != nil {
+		return err
+	}
+	*drifts = append(*drifts, roleDrifts...)
+	return nil
+}
+
+// roleAttributes are the attributes of a role as stored in pg_roles
+type roleAttributes struct {
+	login, createDB, createRole, inherit, replication, bypassRLS bool
+	connectionLimit                                              int32
+	// validUntil is empty when the role never expires
+	validUntil string
+}
+
+// createRoleDefaults are the attributes CREATE ROLE gives to a role when it has no options
+var createRoleDefaults = roleAttributes{inherit: true, connectionLimit: -1}
+
+func desiredRoleAttributes(account *v1.PostgreSQLAccountSpec) roleAttributes {
+	desired := roleAttributes{
+		login:           true,
+		createDB:        account.CreateDB,
+		createRole:      account.CreateRole,
+		inherit:         true,
+		replication:     account.Replication,
+		bypassRLS:       account.BypassRLS,
+		connectionLimit: -1,
+		validUntil:      account.ValidUntil,
+	}
+	if account.Login != nil {
+		desired.login = *account.Login
+	}
+	if account.Disabled {
+		desired.login = false
+	}
+	if account.Inherit != nil {
+		desired.inherit = *account.Inherit
+	}
+	if account.ConnectionLimit != nil {
+		desired.connectionLimit = *account.ConnectionLimit
+	}
+	return desired
+}
+
+// roleOptions returns the CREATE ROLE or ALTER ROLE options changing the current attributes of the role into the
+// desired ones, together with the drifts they correct. Only the differing attributes are set, as setting
+// REPLICATION or BYPASSRLS requires a superuser even when they do not change.
+func roleOptions(current *roleAttributes, desired roleAttributes, role string) (options, drifts []string) {
+	for _, attribute := range []struct {
+		option           string
+		current, desired bool
+	}{
+		{"LOGIN", current.login, desired.login},
+		{"CREATEDB", current.createDB, desired.createDB},
+		{"CREATEROLE", current.createRole, desired.createRole},
+		{"INHERIT", current.inherit, desired.inherit},
+		{"REPLICATION", current.replication, desired.replication},
+		{"BYPASSRLS", current.bypassRLS, desired.bypassRLS},
+	} {
+		if attribute.current == attribute.desired {
+			continue
+		}
+		currentOption, desiredOption := attribute.option, "NO"+attribute.option
+		if attribute.desired {
+			currentOption, desiredOption = desiredOption, currentOption
+		}
+		options = append(options, desiredOption)
+		drifts = append(drifts, fmt.Sprintf(`role %s was %s instead of %s`, role, currentOption, desiredOption))
+	}
+	if current.connectionLimit != desired.connectionLimit {
+		options = append(options, fmt.Sprintf(`CONNECTION LIMIT %d`, desired.connectionLimit))
+		drifts = append(drifts, fmt.Sprintf(`role %s connection limit was %d instead of %d`, role, current.connectionLimit, desired.connectionLimit))
+	}
+	if current.validUntil != desired.validUntil {
+		validUntil := `'infinity'`
+		if desired.validUntil != "" {
+			validUntil = pgsql.QuoteLiteral(desired.validUntil)
+		}
+		options = append(options, `VALID UNTIL `+validUntil)
+		drifts = append(drifts, fmt.Sprintf(`role %s valid until was '%s' instead of '%s'`, role, current.validUntil, desired.validUntil))
+	}
+	return options, drifts
+}
+
+// readPasswordVerifier returns the password verifier stored for the role, empty when it has no password.
+// known is false when the operator is not allowed to read pg_authid, which requires a superuser.
+func (r *PostgreSQLAccountReconciler) readPasswordVerifier(db *sql.DB, account *v1.PostgreSQLAccountSpec) (verifier string, known bool, err error) {
+	query := `SELECT COALESCE(rolpassword, '') FROM pg_catalog.pg_authid WHERE rolname = $1`
+	err = db.QueryRow(query, account.Name).Scan(&verifier)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "insufficient_privilege" {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf(`error executing query %s for account %s : %w`, query, account.Name, err)
+	}
+	return verifier, true, nil
+}
+
+func passwordMatches(verifier, password string) bool {
+	if verifier == "" {
+		return password == ""
+	}
+	return scram.Verify(verifier, password)
+}
+
+// passwordClause returns the PASSWORD clause setting the SCRAM-SHA-256 verifier of the password, so the password
+// itself is never sent to the server
+func (r *PostgreSQLAccountReconciler) passwordClause(account *v1.PostgreSQLAccountSpec) (string, error) {
+	if account.Password == "" {
+		return `PASSWORD NULL`, nil
+	}
+	iterations := r.ScramIterations
+	if iterations == 0 {
+		iterations = scram.DefaultIterations
+	}
+	verifier, err := scram.Verifier(account.Password, iterations)
+	if err != nil {
+		return "", fmt.Errorf(`error hashing password for account %s : %w`, account.Name, err)
+	}
+	return `PASSWORD ` + pgsql.QuoteLiteral(verifier), nil
+}
+
+func (r *PostgreSQLAccountReconciler) alterAccount(db *sql.DB, account *v1.PostgreSQLAccountSpec, options []string) error {
+	query := fmt.Sprintf(`ALTER ROLE %s WITH %s`, pgsql.QuoteIdent(account.Name), strings.Join(options, " "))
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf(`error executing query ALTER ROLE ... for account %s : %w`, account.Name, err)
 	}
 	rows.Close()
 	return nil
 }
 
-func (r *PostgreSQLAccountReconciler) readValidUntil(namespacedName *types.NamespacedName, account *v1.PostgreSQLAccountSpec) (*string, error) {
-	query := `SELECT valuntil FROM pg_catalog.pg_user WHERE usename = $1`
-	rows, err := (*r.DBClients)[namespacedName.String()].Query(query, account.Name)
+// readRole returns the attributes of the role, or nil when the role does not exist
+func (r *PostgreSQLAccountReconciler) readRole(db *sql.DB, account *v1.PostgreSQLAccountSpec) (*roleAttributes, error) {
+	query := `SELECT rolcanlogin, rolcreatedb, rolcreaterole, rolinherit, rolreplication, rolbypassrls, rolconnlimit,
+		COALESCE(to_char(rolvaliduntil, 'YYYY-MM-DD'), '') FROM pg_catalog.pg_roles WHERE rolname = $1`
+	rows, err := db.Query(query, account.Name)
 	if err != nil {
 		return nil, fmt.Errorf(`error executing query %s for account %s : %w`, query, account.Name, err)
 	}
@@ -131,40 +783,287 @@ func (r *PostgreSQLAccountReconciler) readValidUntil(namespacedName *types.Names
 	if err != nil {
 		return nil, fmt.Errorf(`error iterating configuration from db for account %s : %w`, account.Name, err)
 	}
-	var result string
-	err = rows.Scan(&result)
+	result := &roleAttributes{}
+	err = rows.Scan(&result.login, &result.createDB, &result.createRole, &result.inherit, &result.replication,
+		&result.bypassRLS, &result.connectionLimit, &result.validUntil)
 	if err != nil {
 		return nil, fmt.Errorf(`error reading configuration from db for account %s : %w`, account.Name, err)
 	}
-	return &result, nil
+	return result, nil
 }
 
-func (r *PostgreSQLAccountReconciler) createAccount(namespacedName *types.NamespacedName, account *v1.PostgreSQLAccountSpec) error {
-
-	query := fmt.Sprintf(`CREATE USER %s WITH PASSWORD '%s'`, account.Name, account.Password)
-	if account.ValidUntil != "" {
-		query = fmt.Sprintf("%s VALID UNTIL '%s'", query, account.ValidUntil)
+func (r *PostgreSQLAccountReconciler) createAccount(db *sql.DB, account *v1.PostgreSQLAccountSpec) error {
+	options, _ := roleOptions(&createRoleDefaults, desiredRoleAttributes(account), account.Name)
+	password, err := r.passwordClause(account)
+	if err != nil {
+		return err
 	}
+	query := fmt.Sprintf(`CREATE ROLE %s WITH %s`, pgsql.QuoteIdent(account.Name), strings.Join(append(options, password), " "))
 
-	rows, err := (*r.DBClients)[namespacedName.String()].Query(query)
+	rows, err := db.Query(query)
 	if err != nil {
-		return fmt.Errorf(`error executing query %s for account %s : %w`, query, account.Name, err)
+		// the query holds the password, so it is not part of the error
+		return fmt.Errorf(`error executing query CREATE ROLE ... for account %s : %w`, account.Name, err)
 	}
 	rows.Close()
 	return nil
 }
 
+// membership is a role membership as stored in pg_auth_members
+type membership struct {
+	admin, inherit, set bool
+}
+
+// upsertMemberships grants the roles of MemberOf to the account and revokes the ones the operator granted before
+// and are no longer listed, recording the granted roles in the status. The drifts it corrects are appended to
+// drifts, and an observed account only gets the differences with the spec appended.
+func (r *PostgreSQLAccountReconciler) upsertMemberships(db *sql.DB, account *v1.PostgreSQLAccount, drifts *[]string) error {
+	spec := &account.Spec
+	if len(spec.MemberOf) == 0 && len(account.Status.MemberOf) == 0 {
+		return nil
+	}
+	var version int
+	if err := db.QueryRow(`SELECT current_setting('server_version_num')::int`).Scan(&version); err != nil {
+		return fmt.Errorf(`error reading server version for account %s : %w`, spec.Name, err)
+	}
+	// the INHERIT and SET options of a membership were added in PostgreSQL 16
+	options := version >= 160000
+	current, err := readMemberships(db, spec.Name, options)
+	if err != nil {
+		return err
+	}
+	manage := !observed(spec.ManagementPolicy)
+	granted := map[string]bool{}
+	for _, role := range account.Status.MemberOf {
+		granted[role] = true
+	}
+	defer func() {
+		if manage {
+			account.Status.MemberOf = sortedKeys(granted)
+		}
+	}()
+	listed := map[string]bool{}
+	for _, m := range spec.MemberOf {
+		listed[m.Role] = true
+	}
+	for role := range granted {
+		if listed[role] {
+			continue
+		}
+		if _, ok := current[role]; ok && manage {
+			if err = execMembership(db, fmt.Sprintf(`REVOKE %s FROM %s`, pgsql.QuoteIdent(role), pgsql.QuoteIdent(spec.Name)), spec.Name); err != nil {
+				return err
+			}
+		}
+		delete(granted, role)
+	}
+	// an observed account reports what differs now, a managed one what it corrected
+	was := "was"
+	if !manage {
+		was = "is"
+	}
+	for i := range spec.MemberOf {
+		m := &spec.MemberOf[i]
+		if !options && (m.InheritOption != nil || m.SetOption != nil) {
+			return fmt.Errorf(`inheritOption and setOption of role %s require PostgreSQL 16`, m.Role)
+		}
+		have, ok := current[m.Role]
+		inheritDiffers := ok && m.InheritOption != nil && have.inherit != *m.InheritOption
+		setDiffers := ok && m.SetOption != nil && have.set != *m.SetOption
+		var differences []string
+		if !ok {
+			differences = append(differences, fmt.Sprintf(`role %s %s not a member of %s`, spec.Name, was, m.Role))
+		}
+		if ok && have.admin != m.AdminOption {
+			differences = append(differences, fmt.Sprintf(`role %s admin option on %s %s %t instead of %t`, spec.Name, m.Role, was, have.admin, m.AdminOption))
+		}
+		if inheritDiffers {
+			differences = append(differences, fmt.Sprintf(`role %s inherit option on %s %s %t instead of %t`, spec.Name, m.Role, was, have.inherit, *m.InheritOption))
+		}
+		if setDiffers {
+			differences = append(differences, fmt.Sprintf(`role %s set option on %s %s %t instead of %t`, spec.Name, m.Role, was, have.set, *m.SetOption))
+		}
+		if !manage {
+			*drifts = append(*drifts, differences...)
+			continue
+		}
+		if len(differences) == 0 {
+			granted[m.Role] = true
+			continue
+		}
+		if !ok {
+			exists, err := roleExists(db, m.Role)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return dependencyError(fmt.Errorf(`role %s does not exist`, m.Role))
+			}
+		}
+		if ok && have.admin && !m.AdminOption {
+			query := fmt.Sprintf(`REVOKE ADMIN OPTION FOR %s FROM %s`, pgsql.QuoteIdent(m.Role), pgsql.QuoteIdent(spec.Name))
+			if err = execMembership(db, query, spec.Name); err != nil {
+				return err
+			}
+		}
+		// on PostgreSQL 16 granting an existing membership again updates its options
+		if !ok || m.AdminOption && !have.admin || inheritDiffers || setDiffers {
+			if err = execMembership(db, grantMembership(m, spec.Name, options), spec.Name); err != nil {
+				return err
+			}
+		}
+		granted[m.Role] = true
+		*drifts = append(*drifts, differences...)
+	}
+	return nil
+}
+
+// upsertAccountParameters converges the parameters of the role, and the ones of the role in the database the
+// connection points to, appending the drifts to drifts
+func (r *PostgreSQLAccountReconciler) upsertAccountParameters(db *sql.DB, account *v1.PostgreSQLAccountSpec, drifts *[]string) error {
+	observe := observed(account.ManagementPolicy)
+	roleDrifts, err := upsertParameters(db, settingsScope{role: account.Name}, account.Parameters, observe)
+	if err != nil {
+		return err
+	}
+	*drifts = append(*drifts, roleDrifts...)
+	if account.DatabaseParameters == nil {
+		return nil
+	}
+	var database string
+	if err = db.QueryRow(`SELECT current_database()`).Scan(&database); err != nil {
+		return fmt.Errorf(`error reading database of account %s : %w`, account.Name, err)
+	}
+	databaseDrifts, err := upsertParameters(db, settingsScope{database: database, role: account.Name}, account.DatabaseParameters, observe)
+	if err != nil {
+		return err
+	}
+	*drifts = append(*drifts, databaseDrifts...)
+	return nil
+}
+
+// grantMembership returns the GRANT making the account a member of the role with the options of the membership
+func grantMembership(m *v1.RoleMembership, member string, options bool) string {
+	query := fmt.Sprintf(`GRANT %s TO %s`, pgsql.QuoteIdent(m.Role), pgsql.QuoteIdent(member))
+	if !options {
+		if m.AdminOption {
+			query += ` WITH ADMIN OPTION`
+		}
+		return query
+	}
+	var with []string
+	if m.AdminOption {
+		with = append(with, `ADMIN TRUE`)
+	}
+	if m.InheritOption != nil {
+		with = append(with, fmt.Sprintf(`INHERIT %t`, *m.InheritOption))
+	}
+	if m.SetOption != nil {
+		with = append(with, fmt.Sprintf(`SET %t`, *m.SetOption))
+	}
+	if len(with) > 0 {
+		query += ` WITH ` + strings.Join(with, ", ")
+	}
+	return query
+}
+
+func execMembership(db *sql.DB, query, member string) error {
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf(`error executing query %s for account %s : %w`, query, member, err)
+	}
+	return nil
+}
+
+// readMemberships returns the memberships of the role by role name. The grants of a role made by different
+// grantors are merged, the inherit and set options are only read when the server supports them.
+func readMemberships(db *sql.DB, member string, options bool) (map[string]membership, error) {
+	columns := `bool_or(m.admin_option), false, false`
+	if options {
+		columns = `bool_or(m.admin_option), bool_or(m.inherit_option), bool_or(m.set_option)`
+	}
+	query := fmt.Sprintf(`SELECT g.rolname, %s FROM pg_catalog.pg_auth_members m
+		JOIN pg_catalog.pg_roles g ON g.oid = m.roleid JOIN pg_catalog.pg_roles r ON r.oid = m.member
+		WHERE r.rolname = $1 GROUP BY g.rolname`, columns)
+	rows, err := db.Query(query, member)
+	if err != nil {
+		return nil, fmt.Errorf(`error executing query %s for account %s : %w`, query, member, err)
+	}
+	defer rows.Close()
+	memberships := map[string]membership{}
+	for rows.Next() {
+		var role string
+		var m membership
+		if err = rows.Scan(&role, &m.admin, &m.inherit, &m.set); err != nil {
+			return nil, fmt.Errorf(`error reading memberships of account %s : %w`, member, err)
+		}
+		memberships[role] = m
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf(`error iterating memberships of account %s : %w`, member, err)
+	}
+	return memberships, nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateAccount checks the spec, the password policies being enforced apart by enforcePasswordPolicies
 func validateAccount(spec *v1.PostgreSQLAccountSpec) error {
-	if !validPostgresName(spec.Name) {
+	if spec.PostgreSQLDatabaseName == "" {
+		return fmt.Errorf(`postgreSQLDatabaseName is required`)
+	}
+	if !pgsql.ValidName(spec.Name) {
 		return fmt.Errorf(`invalid name %s`, spec.Name)
 	}
 	if !validDate(spec.ValidUntil) {
 		return fmt.Errorf(`invalid date valid_until %s`, spec.ValidUntil)
 	}
+	if spec.ConnectionLimit != nil && *spec.ConnectionLimit < -1 {
+		return fmt.Errorf(`invalid connectionLimit %d`, *spec.ConnectionLimit)
+	}
+	roles := map[string]bool{}
+	for _, m := range spec.MemberOf {
+		if !pgsql.ValidName(m.Role) || m.Role == spec.Name {
+			return fmt.Errorf(`invalid memberOf role %s`, m.Role)
+		}
+		if roles[m.Role] {
+			return fmt.Errorf(`duplicated memberOf role %s`, m.Role)
+		}
+		roles[m.Role] = true
+	}
+	if err := validateParameters("parameter", spec.Parameters); err != nil {
+		return err
+	}
+	if err := validateParameters("databaseParameter", spec.DatabaseParameters); err != nil {
+		return err
+	}
+	if spec.ReassignOwnedTo != "" && !pgsql.ValidName(spec.ReassignOwnedTo) {
+		return fmt.Errorf(`invalid reassignOwnedTo %s`, spec.ReassignOwnedTo)
+	}
+	if spec.ConnectionSecret != nil {
+		for key, text := range spec.ConnectionSecret.Keys {
+			if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+				return fmt.Errorf(`invalid connection secret key %s : %v`, key, errs)
+			}
+			if _, err := template.New(key).Parse(text); err != nil {
+				return fmt.Errorf(`invalid template for connection secret key %s : %w`, key, err)
+			}
+		}
+	}
 	return nil
 }
 
+// validDate accepts the empty date, which means no expiration
 func validDate(date string) bool {
+	if date == "" {
+		return true
+	}
 	_, err := time.Parse("2006-01-02", date)
 	return err == nil
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v1 "database-account-operator/api/v1"
+	"database-account-operator/pkg/pgsql"
+)
+
+// PostgreSQLAccountWebhook defaults and validates PostgreSQLAccount api resources on admission
+type PostgreSQLAccountWebhook struct {
+	// Client reads the PostgreSQLPasswordPolicies enforced on the accounts
+	Client client.Reader
+}
+
+var _ admission.CustomDefaulter = &PostgreSQLAccountWebhook{}
+var _ admission.CustomValidator = &PostgreSQLAccountWebhook{}
+
+// SetupWebhookWithManager sets up the webhook with the Manager.
+func (w *PostgreSQLAccountWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1.PostgreSQLAccount{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-database-account-operator-my-domain-v1-postgresqlaccount,mutating=true,failurePolicy=fail,sideEffects=None,groups=database-account-operator.my.domain,resources=postgresqlaccounts,verbs=create,versions=v1,name=mpostgresqlaccount.kb.io,admissionReviewVersions=v1
+
+// Default names the role after the api resource when the name is omitted, replacing the characters
+// Kubernetes names allow but PostgreSQL names do not with underscores
+func (w *PostgreSQLAccountWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	account := obj.(*v1.PostgreSQLAccount)
+	if account.Spec.Name == "" {
+		account.Spec.Name = roleName(account.Name)
+	}
+	return nil
+}
+
+// roleName returns the role name of an api resource name. The names longer than PostgreSQL identifiers are cut,
+// ending with a hash of the whole name so two api resources sharing a prefix keep distinct roles.
+func roleName(name string) string {
+	role := strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	if len(role) <= pgsql.MaxNameLength {
+		return role
+	}
+	hash := sha256.Sum256([]byte(name))
+	suffix := "_" + hex.EncodeToString(hash[:4])
+	return role[:pgsql.MaxNameLength-len(suffix)] + suffix
+}
+
+//+kubebuilder:webhook:path=/validate-database-account-operator-my-domain-v1-postgresqlaccount,mutating=false,failurePolicy=fail,sideEffects=None,groups=database-account-operator.my.domain,resources=postgresqlaccounts,verbs=create;update,versions=v1,name=vpostgresqlaccount.kb.io,admissionReviewVersions=v1
+
+// ValidateCreate rejects an invalid spec or one breaking the password policies
+func (w *PostgreSQLAccountWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	account := obj.(*v1.PostgreSQLAccount)
+	if err := validateAccount(&account.Spec); err != nil {
+		return err
+	}
+	return w.enforcePasswordPolicies(ctx, account)
+}
+
+// ValidateUpdate rejects an invalid spec and changes to the role name or its database. The password policies are
+// only enforced when the password or valid_until change, so the accounts created before a policy can still be
+// updated, and their finalizer added, until they rotate their password.
+func (w *PostgreSQLAccountWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldAccount, newAccount := oldObj.(*v1.PostgreSQLAccount), newObj.(*v1.PostgreSQLAccount)
+	// the finalizer must be removable whatever the spec is
+	if !newAccount.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	// an account must be disabled or enabled again whatever the spec is
+	if onlyDisabledChanged(&oldAccount.Spec, &newAccount.Spec) {
+		return nil
+	}
+	if err := validateAccount(&newAccount.Spec); err != nil {
+		return err
+	}
+	if oldAccount.Spec.Password != newAccount.Spec.Password || oldAccount.Spec.ValidUntil != newAccount.Spec.ValidUntil {
+		if err := w.enforcePasswordPolicies(ctx, newAccount); err != nil {
+			return err
+		}
+	}
+	for field, values := range map[string][2]string{
+		"name":                   {oldAccount.Spec.Name, newAccount.Spec.Name},
+		"postgreSQLDatabaseName": {oldAccount.Spec.PostgreSQLDatabaseName, newAccount.Spec.PostgreSQLDatabaseName},
+	} {
+		if values[0] != values[1] {
+			return fmt.Errorf(`%s is immutable, it cannot change from %s to %s`, field, values[0], values[1])
+		}
+	}
+	return nil
+}
+
+// enforcePasswordPolicies checks the spec against the password policies applying to the namespace of the account
+func (w *PostgreSQLAccountWebhook) enforcePasswordPolicies(ctx context.Context, account *v1.PostgreSQLAccount) error {
+	policies, err := passwordPolicies(ctx, w.Client, account.Namespace)
+	if err != nil {
+		return err
+	}
+	return enforcePasswordPolicies(&account.Spec, policies)
+}
+
+// onlyDisabledChanged tells whether the specs differ by disabled alone
+func onlyDisabledChanged(oldSpec, newSpec *v1.PostgreSQLAccountSpec) bool {
+	if oldSpec.Disabled == newSpec.Disabled {
+		return false
+	}
+	spec := *oldSpec
+	spec.Disabled = newSpec.Disabled
+	return equality.Semantic.DeepEqual(&spec, newSpec)
+}
+
+// ValidateDelete allows every deletion
+func (w *PostgreSQLAccountWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
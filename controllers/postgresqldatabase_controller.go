@@ -19,37 +19,112 @@ package controllers
 import (
 	"context"
 	v1 "database-account-operator/api/v1"
+	"database-account-operator/pkg/pgsql"
 	"database/sql"
 	"fmt"
+	"net"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	_ "github.com/lib/pq"
-	"golang.org/x/text/language"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// databaseNameField indexes the PostgreSQLAccounts and PostgreSQLGrants by the PostgreSQLDatabase they reference
+const databaseNameField = ".spec.postgreSQLDatabaseName"
+
 // PostgreSQLDatabaseReconciler reconciles a PostgreSQLDatabase object
 type PostgreSQLDatabaseReconciler struct {
 	client.Client
-	Scheme         *runtime.Scheme
-	DBClients      *map[string]*sql.DB
-	previousDBSpec *v1.PostgreSQLDatabaseSpec
+	Scheme      *runtime.Scheme
+	Connections *ConnectionRegistry
+	Recorder    record.EventRecorder
+	// ResyncInterval is how often the databases are checked for drift, zero disabling the checks
+	ResyncInterval    time.Duration
+	dependencyBackoff workqueue.RateLimiter
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PostgreSQLDatabaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.dependencyBackoff = newDependencyBackoff()
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1.PostgreSQLDatabase{}).
+		For(&v1.PostgreSQLDatabase{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findDatabasesForSecret)).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.findDatabasesForConfigMap)).
+		Watches(&source.Kind{Type: &v1.PostgreSQLServer{}}, handler.EnqueueRequestsFromMapFunc(r.findDatabasesForServer)).
 		Complete(r)
 }
 
+// findDatabasesForServer maps a PostgreSQLServer to the PostgreSQLDatabases using it,
+// so they pick up its admin connection whenever it is reopened
+func (r *PostgreSQLDatabaseReconciler) findDatabasesForServer(server client.Object) []reconcile.Request {
+	dbList := &v1.PostgreSQLDatabaseList{}
+	if err := r.List(context.Background(), dbList); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, db := range dbList.Items {
+		if db.Spec.ServerRef == server.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: db.Name, Namespace: db.Namespace}})
+		}
+	}
+	return requests
+}
+
+// findDatabasesForSecret maps a Secret to the PostgreSQLDatabases reading their credentials or certificates from it,
+// so a rotated admin password or certificate reopens the connection
+func (r *PostgreSQLDatabaseReconciler) findDatabasesForSecret(secret client.Object) []reconcile.Request {
+	dbList := &v1.PostgreSQLDatabaseList{}
+	if err := r.List(context.Background(), dbList, client.InNamespace(secret.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, db := range dbList.Items {
+		ref := db.Spec.CredentialsSecretRef
+		if ref != nil && ref.Name == secret.GetName() || tlsReferencesSecret(db.Spec.TLS, secret.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: db.Name, Namespace: db.Namespace}})
+		}
+	}
+	return requests
+}
+
+// findDatabasesForConfigMap maps a ConfigMap to the PostgreSQLDatabases reading their CA bundle from it
+func (r *PostgreSQLDatabaseReconciler) findDatabasesForConfigMap(configMap client.Object) []reconcile.Request {
+	dbList := &v1.PostgreSQLDatabaseList{}
+	if err := r.List(context.Background(), dbList, client.InNamespace(configMap.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, db := range dbList.Items {
+		if tlsReferencesConfigMap(db.Spec.TLS, configMap.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: db.Name, Namespace: db.Namespace}})
+		}
+	}
+	return requests
+}
+
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqldatabases,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqldatabases/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqldatabases/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -59,97 +134,293 @@ func (r *PostgreSQLDatabaseReconciler) SetupWithManager(mgr ctrl.Manager) error
 func (r *PostgreSQLDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	dbApiResource := &v1.PostgreSQLDatabase{}
 	namespacedName := types.NamespacedName{Name: req.Name, Namespace: req.Namespace}
-	r.Get(ctx, namespacedName, dbApiResource)
+	if err := r.Get(ctx, namespacedName, dbApiResource); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !dbApiResource.DeletionTimestamp.IsZero() {
+		return r.finalizeDatabase(ctx, dbApiResource)
+	}
+	if !controllerutil.ContainsFinalizer(dbApiResource, finalizerName) {
+		// patched so the fields the spec omits when encoded, like empty parameters, are kept
+		patch := client.MergeFrom(dbApiResource.DeepCopy())
+		controllerutil.AddFinalizer(dbApiResource, finalizerName)
+		if err := r.Patch(ctx, dbApiResource, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 	dbSpec := dbApiResource.Spec
-	dbStatus := dbApiResource.Status
+	wasInSync := inSync(&dbApiResource.Status.ReconcileStatus, dbApiResource.Generation)
 
 	var e error
+	var drifts []string
 	if err := validateDatabase(&dbSpec); err != nil {
-		dbStatus.Error = err.Error()
-	} else if err := r.dbOpen(&namespacedName, &dbSpec); err != nil {
-		dbStatus.Error = err.Error()
-	} else if err = r.createDBIfNotExists(&namespacedName, &dbSpec); err != nil {
-		dbStatus.Error = err.Error()
+		e = invalidSpecError(err)
+	} else if conn, err := r.connect(ctx, &namespacedName, &dbSpec); err != nil {
+		e = connectionError(err)
+	} else if adminClient, release, err := r.acquireAdmin(ctx, &namespacedName, &dbSpec); err != nil {
+		e = connectionError(err)
 	} else {
-		dbStatus.Error = ""
-		r.previousDBSpec = &dbSpec
+		if drifts, err = r.createDBIfNotExists(ctx, adminClient, dbApiResource, &dbSpec); err != nil {
+			e = err
+		} else if len(drifts) == 0 || !observed(dbSpec.ManagementPolicy) {
+			// an observed database may not exist, so it is only connected to when it matches the spec
+			parameterDrifts, err := upsertParameters(adminClient, settingsScope{database: dbSpec.Database}, dbSpec.Parameters, observed(dbSpec.ManagementPolicy))
+			if err != nil {
+				e = err
+			} else if err = r.openDatabase(ctx, &namespacedName, &dbSpec, conn); err != nil {
+				e = connectionError(err)
+			}
+			drifts = append(drifts, parameterDrifts...)
+		}
+		release()
 	}
 
-	dbStatus.Ready = e == nil
-	if e != nil {
-		dbStatus.Error = e.Error()
-	} else {
-		dbStatus.Error = ""
+	setReconcileStatus(&dbApiResource.Status.ReconcileStatus, dbApiResource.Generation, e)
+	if e == nil {
+		if !wasInSync && !observed(dbSpec.ManagementPolicy) {
+			drifts = nil
+		}
+		recordDrift(r.Recorder, dbApiResource, &dbApiResource.Status.ReconcileStatus, drifts, observed(dbSpec.ManagementPolicy))
+	}
+	if err := r.Status().Update(ctx, dbApiResource); err != nil {
+		return ctrl.Result{}, err
 	}
-	r.Status().Update(ctx, dbApiResource)
-	log.FromContext(ctx).Info("Reconciled", "req", req, "dbSpec", dbSpec, "dbStatus", dbStatus)
-	return ctrl.Result{}, e
+	log.FromContext(ctx).Info("Reconciled", "req", req, "dbSpec", dbSpec, "dbStatus", dbApiResource.Status)
+	return reconcileResult(r.dependencyBackoff, r.ResyncInterval, req, e)
 }
 
-func (r *PostgreSQLDatabaseReconciler) dbOpen(namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec) error {
-
-	dbClient := (*r.DBClients)[namespacedName.String()]
-	if dbClient == nil ||
-		r.previousDBSpec == nil ||
-		r.previousDBSpec.User != dbSpec.User ||
-		r.previousDBSpec.Password != dbSpec.Password ||
-		r.previousDBSpec.Address != dbSpec.Address {
-		if dbClient != nil {
-			(*r.DBClients)[namespacedName.String()] = nil
-			err := dbClient.Close()
-			if err != nil {
-				return err
-			}
+// dependentsRequeueDelay is how long the deletion of a PostgreSQLDatabase waits for its dependents to go away
+const dependentsRequeueDelay = 10 * time.Second
+
+// finalizeDatabase closes and evicts the db client, dropping the database first when the deletion policy says so.
+// It waits while PostgreSQLAccounts or PostgreSQLGrants still reference the database.
+func (r *PostgreSQLDatabaseReconciler) finalizeDatabase(ctx context.Context, dbApiResource *v1.PostgreSQLDatabase) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(dbApiResource, finalizerName) {
+		return ctrl.Result{}, nil
+	}
+	namespacedName := types.NamespacedName{Name: dbApiResource.Name, Namespace: dbApiResource.Namespace}
+	dependents, err := r.dependents(ctx, &namespacedName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(dependents) > 0 {
+		e := &reconcileError{
+			reason: reasonDependentsExist,
+			err:    fmt.Errorf("database is still referenced by %s", strings.Join(dependents, ", ")),
+		}
+		setReconcileStatus(&dbApiResource.Status.ReconcileStatus, dbApiResource.Generation, e)
+		if err = r.Status().Update(ctx, dbApiResource); err != nil {
+			return ctrl.Result{}, err
 		}
-		//TODO: Enable ssl configuration
-		connStr := fmt.Sprintf("postgresql://%s:%s@%s?sslmode=disable", dbSpec.User, dbSpec.Password, dbSpec.Address)
-		db, err := sql.Open("postgres", connStr)
+		return ctrl.Result{RequeueAfter: dependentsRequeueDelay}, nil
+	}
+
+	dbSpec := &dbApiResource.Spec
+	// an invalid spec was never created, so there is nothing to drop
+	if dbSpec.DeletionPolicy == v1.DeletionPolicyDelete && !observed(dbSpec.ManagementPolicy) &&
+		created(dbApiResource) && validateDatabase(dbSpec) == nil {
+		if _, err = r.connect(ctx, &namespacedName, dbSpec); err != nil {
+			return ctrl.Result{}, err
+		}
+		adminClient, release, err := r.acquireAdmin(ctx, &namespacedName, dbSpec)
 		if err != nil {
-			return err
+			return ctrl.Result{}, err
 		}
-		(*r.DBClients)[namespacedName.String()] = db
+		err = r.dropDatabase(adminClient, dbSpec)
+		release()
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	r.closeDatabase(&namespacedName, dbSpec)
+	controllerutil.RemoveFinalizer(dbApiResource, finalizerName)
+	return ctrl.Result{}, r.Update(ctx, dbApiResource)
+}
+
+// dependents lists the PostgreSQLAccounts and PostgreSQLGrants referencing the database
+func (r *PostgreSQLDatabaseReconciler) dependents(ctx context.Context, namespacedName *types.NamespacedName) ([]string, error) {
+	var dependents []string
+	references := client.MatchingFields{databaseNameField: namespacedName.Name}
+	accountList := &v1.PostgreSQLAccountList{}
+	if err := r.List(ctx, accountList, client.InNamespace(namespacedName.Namespace), references); err != nil {
+		return nil, err
+	}
+	for _, account := range accountList.Items {
+		dependents = append(dependents, "PostgreSQLAccount "+account.Name)
+	}
+	grantList := &v1.PostgreSQLGrantList{}
+	if err := r.List(ctx, grantList, client.InNamespace(namespacedName.Namespace), references); err != nil {
+		return nil, err
+	}
+	for _, grant := range grantList.Items {
+		dependents = append(dependents, "PostgreSQLGrant "+grant.Name)
+	}
+	return dependents, nil
+}
+
+// dropDatabase terminates the backends connected to the database and drops it
+func (r *PostgreSQLDatabaseReconciler) dropDatabase(adminClient *sql.DB, dbSpec *v1.PostgreSQLDatabaseSpec) error {
+	query := `SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()`
+	rows, err := adminClient.Query(query, dbSpec.Database)
+	if err != nil {
+		return fmt.Errorf(`error executing query %s for database %s : %w`, query, dbSpec.Database, err)
+	}
+	rows.Close()
+	query = fmt.Sprintf(`DROP DATABASE IF EXISTS %s`, pgsql.QuoteIdent(dbSpec.Database))
+	rows, err = adminClient.Query(query)
+	if err != nil {
+		return fmt.Errorf(`error executing query %s %w`, query, err)
 	}
+	rows.Close()
 	return nil
 }
 
+// connect resolves the connection to the server hosting the database and opens its admin connection when it is
+// declared inline in the spec
+func (r *PostgreSQLDatabaseReconciler) connect(ctx context.Context, namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec) (*serverConnection, error) {
+	conn, err := resolveConnection(ctx, r.Client, namespacedName.Namespace, dbSpec)
+	if err != nil {
+		return nil, err
+	}
+	if dbSpec.ServerRef != "" {
+		// the admin connection of a PostgreSQLServer is opened by its reconciler,
+		// the database may have moved to it from an inline connection
+		r.Connections.Evict(inlineConnectionKey(namespacedName))
+	} else if err = r.Connections.Open(inlineConnectionKey(namespacedName), conn.connectionString("")); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// acquireAdmin returns the admin connection of the server hosting the database once it is reachable,
+// the release function must be called when done with it
+func (r *PostgreSQLDatabaseReconciler) acquireAdmin(ctx context.Context, namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec) (*sql.DB, func(), error) {
+	adminClient, release, ok := r.Connections.Acquire(connectionKey(namespacedName, dbSpec))
+	if !ok {
+		return nil, nil, dependencyError(fmt.Errorf("unable to find db client for PostgreSQLServer, is there a PostgreSQLServer api resource with name %s in ready status?", dbSpec.ServerRef))
+	}
+	if err := adminClient.PingContext(ctx); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return adminClient, release, nil
+}
+
+// openDatabase opens the connection to the database itself, shared with the PostgreSQLAccounts and
+// PostgreSQLGrants so their schemas and grants land in the database
+func (r *PostgreSQLDatabaseReconciler) openDatabase(ctx context.Context, namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec, conn *serverConnection) error {
+	key := databaseConnectionKey(connectionKey(namespacedName, dbSpec), dbSpec.Database)
+	if err := r.Connections.Open(key, conn.connectionString(dbSpec.Database)); err != nil {
+		return err
+	}
+	return r.Connections.Ping(ctx, key)
+}
+
+// closeDatabase evicts the connections owned by the database
+func (r *PostgreSQLDatabaseReconciler) closeDatabase(namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec) {
+	r.Connections.Evict(databaseConnectionKey(connectionKey(namespacedName, dbSpec), dbSpec.Database))
+	// the admin connection of a PostgreSQLServer is shared, only the one declared inline is closed
+	r.Connections.Evict(inlineConnectionKey(namespacedName))
+}
+
+func readSecretKey(ctx context.Context, c client.Client, secretName types.NamespacedName, key string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return "", fmt.Errorf(`error reading secret %s : %w`, secretName.String(), err)
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf(`key %s not found in secret %s`, key, secretName.String())
+	}
+	return string(value), nil
+}
+
 //TODO: Make it atomic, possible solution here: https://stackoverflow.com/questions/18389124/simulate-create-database-if-not-exists-for-postgresql
 // It is not critical because race conditions will be solved in the next reconcile cycle
-func (r *PostgreSQLDatabaseReconciler) createDBIfNotExists(namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec) error {
-	dbConf, err := r.readDBConfig(namespacedName, dbSpec.Database)
+// The returned drifts describe what had to be changed to match the spec, or what differs from it when the
+// database is observed. The api resource is marked as created before the database is created, and an existing
+// database is only adopted under the Retain deletion policy, so deleting the api resource never drops a database
+// someone else created.
+func (r *PostgreSQLDatabaseReconciler) createDBIfNotExists(ctx context.Context, adminClient *sql.DB, dbApiResource *v1.PostgreSQLDatabase, dbSpec *v1.PostgreSQLDatabaseSpec) ([]string, error) {
+	dbConf, err := r.readDBConfig(adminClient, dbSpec.Database)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if dbConf == nil {
-		return r.createDB(namespacedName, dbSpec)
+		if observed(dbSpec.ManagementPolicy) {
+			return []string{fmt.Sprintf(`database %s does not exist`, dbSpec.Database)}, nil
+		}
+		if err = markCreated(ctx, r.Client, dbApiResource); err != nil {
+			return nil, err
+		}
+		if err = r.createDB(adminClient, dbSpec); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf(`database %s did not exist`, dbSpec.Database)}, nil
 	}
-	if dbSpec.Encoding != "" && dbConf.encoding != dbSpec.Encoding {
-		return fmt.Errorf("database %s current encoding is %s but desired encoding %s, please backup and delete manually the existing database",
-			dbSpec.Database, dbConf.encoding, dbSpec.Encoding)
+	if dbSpec.DeletionPolicy == v1.DeletionPolicyDelete && !created(dbApiResource) && !observed(dbSpec.ManagementPolicy) {
+		return nil, fmt.Errorf(`database %s already exists and was not created by the operator, its deletionPolicy must be Retain to adopt it`, dbSpec.Database)
 	}
-	if dbSpec.LC_Collate != "" && dbConf.collate != dbSpec.LC_Collate {
-		return fmt.Errorf("database %s current LC_Collate is %s but desired LC_Collate %s, please backup and delete manually the existing database",
-			dbSpec.Database, dbConf.collate, dbSpec.LC_Collate)
+	// the values filled in by the defaulting webhook are only requirements for the databases the operator creates
+	required := func(field, value string) bool {
+		return value != "" && (created(dbApiResource) || !defaulted(dbApiResource, field))
 	}
-	if dbSpec.LC_CType != "" && dbConf.ctype != dbSpec.LC_CType {
-		return fmt.Errorf("database %s current LC_CType is %s but desired LC_CType %s, please backup and delete manually the existing database",
-			dbSpec.Database, dbConf.ctype, dbSpec.LC_CType)
+	var differences []string
+	if required("encoding", dbSpec.Encoding) && dbConf.encoding != dbSpec.Encoding {
+		differences = append(differences, fmt.Sprintf("database %s current encoding is %s but desired encoding %s",
+			dbSpec.Database, dbConf.encoding, dbSpec.Encoding))
 	}
-	return nil
+	if required("lc_collate", dbSpec.LC_Collate) && !sameLocale(dbConf.collate, dbSpec.LC_Collate) {
+		differences = append(differences, fmt.Sprintf("database %s current LC_Collate is %s but desired LC_Collate %s",
+			dbSpec.Database, dbConf.collate, dbSpec.LC_Collate))
+	}
+	if required("lc_ctype", dbSpec.LC_CType) && !sameLocale(dbConf.ctype, dbSpec.LC_CType) {
+		differences = append(differences, fmt.Sprintf("database %s current LC_CType is %s but desired LC_CType %s",
+			dbSpec.Database, dbConf.ctype, dbSpec.LC_CType))
+	}
+	if len(differences) == 0 || observed(dbSpec.ManagementPolicy) {
+		return differences, nil
+	}
+	return nil, fmt.Errorf("%s, please backup and delete manually the existing database", strings.Join(differences, ", "))
 }
 
-func (r *PostgreSQLDatabaseReconciler) createDB(namespacedName *types.NamespacedName, dbSpec *v1.PostgreSQLDatabaseSpec) error {
+// sameLocale compares two locale names the way the C library resolves them, the codeset after the dot ignoring the
+// case and the punctuation, so en_US.UTF-8 is en_US.utf8
+func sameLocale(a, b string) bool {
+	return normalizeLocale(a) == normalizeLocale(b)
+}
+
+func normalizeLocale(locale string) string {
+	dot := strings.IndexByte(locale, '.')
+	if dot < 0 {
+		return locale
+	}
+	codeset := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, locale[dot+1:])
+	return locale[:dot+1] + codeset
+}
+
+func (r *PostgreSQLDatabaseReconciler) createDB(adminClient *sql.DB, dbSpec *v1.PostgreSQLDatabaseSpec) error {
 	//create database does not support parameters
-	query := fmt.Sprintf(`CREATE DATABASE %s`, dbSpec.Database)
+	query := fmt.Sprintf(`CREATE DATABASE %s`, pgsql.QuoteIdent(dbSpec.Database))
+	// template1 may have been created with a different encoding or locale, template0 accepts any of them
+	if dbSpec.Encoding != "" || dbSpec.LC_Collate != "" || dbSpec.LC_CType != "" {
+		query = fmt.Sprintf("%s TEMPLATE template0", query)
+	}
 	if dbSpec.Encoding != "" {
-		query = fmt.Sprintf("%s ENCODING '%s'", query, dbSpec.Encoding)
+		query = fmt.Sprintf("%s ENCODING %s", query, pgsql.QuoteLiteral(dbSpec.Encoding))
 	}
 	if dbSpec.LC_Collate != "" {
-		query = fmt.Sprintf("%s LC_COLLATE '%s'", query, dbSpec.LC_Collate)
+		query = fmt.Sprintf("%s LC_COLLATE %s", query, pgsql.QuoteLiteral(dbSpec.LC_Collate))
 	}
 	if dbSpec.LC_CType != "" {
-		query = fmt.Sprintf("%s LC_CTYPE '%s'", query, dbSpec.LC_CType)
+		query = fmt.Sprintf("%s LC_CTYPE %s", query, pgsql.QuoteLiteral(dbSpec.LC_CType))
 	}
-	rows, err := (*r.DBClients)[namespacedName.String()].Query(query)
+	rows, err := adminClient.Query(query)
 	if err != nil {
 		return fmt.Errorf(`error executing query %s %w`, query, err)
 	}
@@ -157,9 +428,9 @@ func (r *PostgreSQLDatabaseReconciler) createDB(namespacedName *types.Namespaced
 	return nil
 }
 
-func (r *PostgreSQLDatabaseReconciler) readDBConfig(namespacedName *types.NamespacedName, database string) (*dbConfig, error) {
-	query := `SELECT pg_encoding_to_char(encoding),datcollate,datcollate FROM pg_database WHERE datname = $1`
-	rows, err := (*r.DBClients)[namespacedName.String()].Query(query, database)
+func (r *PostgreSQLDatabaseReconciler) readDBConfig(adminClient *sql.DB, database string) (*dbConfig, error) {
+	query := `SELECT pg_encoding_to_char(encoding),datcollate,datctype FROM pg_database WHERE datname = $1`
+	rows, err := adminClient.Query(query, database)
 	if err != nil {
 		return nil, fmt.Errorf(`error executing query %s for database %s : %w`, query, database, err)
 	}
@@ -184,41 +455,53 @@ type dbConfig struct {
 }
 
 func validateDatabase(dbSpec *v1.PostgreSQLDatabaseSpec) error {
-	if !validAddress(dbSpec.Address) {
+	if dbSpec.ServerRef != "" {
+		if dbSpec.Address != "" || dbSpec.User != "" || dbSpec.Password != "" || dbSpec.CredentialsSecretRef != nil || dbSpec.TLS != nil {
+			return fmt.Errorf(`serverRef can not be combined with address, user, password, credentialsSecretRef or tls`)
+		}
+	} else if !validAddress(dbSpec.Address) {
 		return fmt.Errorf(`invalid address %s`, dbSpec.Address)
-	}
-	if !validPostgresName(dbSpec.User) {
+	} else if dbSpec.CredentialsSecretRef != nil {
+		if dbSpec.CredentialsSecretRef.Name == "" {
+			return fmt.Errorf(`credentialsSecretRef.name is required`)
+		}
+	} else if !pgsql.ValidName(dbSpec.User) {
 		return fmt.Errorf(`invalid user %s`, dbSpec.User)
 	}
-	if !validPostgresName(dbSpec.Database) {
+	if err := validateTLS(dbSpec.TLS); err != nil {
+		return err
+	}
+	if !pgsql.ValidName(dbSpec.Database) {
 		return fmt.Errorf(`invalid database name %s`, dbSpec.Database)
 	}
 	if !validEncoding(dbSpec.Encoding) {
 		return fmt.Errorf(`invalid encoding %s`, dbSpec.Encoding)
 	}
-	if !validLocale(dbSpec.LC_Collate) {
+	if !pgsql.ValidLocale(dbSpec.LC_Collate) {
 		return fmt.Errorf(`invalid lc_collate %s`, dbSpec.LC_Collate)
 	}
-	if !validLocale(dbSpec.LC_CType) {
+	if !pgsql.ValidLocale(dbSpec.LC_CType) {
 		return fmt.Errorf(`invalid lc_ctype %s`, dbSpec.LC_CType)
 	}
-	return nil
+	return validateParameters("parameter", dbSpec.Parameters)
 }
 
 func validAddress(dnsPort string) bool {
-	return regexAddress.Match([]byte(dnsPort))
-}
-
-func validPostgresName(name string) bool {
-	return regexPostgresName.Match([]byte(name))
-}
-
-func validLocale(locale string) bool {
-	_, err := language.Parse(locale)
-	return err != nil
+	host, port, err := net.SplitHostPort(dnsPort)
+	if err != nil {
+		return false
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return false
+	}
+	return net.ParseIP(host) != nil || regexHostname.MatchString(host)
 }
 
+// validEncoding accepts the empty encoding, which means the server default
 func validEncoding(encoding string) bool {
+	if encoding == "" {
+		return true
+	}
 	for _, a := range availableEncodings {
 		if a == encoding {
 			return true
@@ -227,10 +510,7 @@ func validEncoding(encoding string) bool {
 	return false
 }
 
-//TODO: support other names supported by postgres
-var regexPostgresName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
-
-var regexAddress = regexp.MustCompile(`^(?:[A-Za-z0-9-]+\.)+[A-Za-z0-9]{1,3}:\d{1,5}$`)
+var regexHostname = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
 
 var availableEncodings = []string{
 	"BIG5",
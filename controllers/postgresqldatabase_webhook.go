@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v1 "database-account-operator/api/v1"
+)
+
+// PostgreSQLDatabaseWebhook defaults and validates PostgreSQLDatabase api resources on admission
+type PostgreSQLDatabaseWebhook struct {
+	// DefaultEncoding, DefaultLCCollate and DefaultLCCType are set on the new databases omitting them,
+	// empty values leave the choice to the server
+	DefaultEncoding  string
+	DefaultLCCollate string
+	DefaultLCCType   string
+}
+
+// defaultedAnnotation lists the fields of a PostgreSQLDatabase filled in by the defaulting webhook, which an existing
+// database does not have to match
+const defaultedAnnotation = "database-account-operator.my.domain/defaulted"
+
+var _ admission.CustomDefaulter = &PostgreSQLDatabaseWebhook{}
+var _ admission.CustomValidator = &PostgreSQLDatabaseWebhook{}
+
+// SetupWebhookWithManager sets up the webhook with the Manager.
+func (w *PostgreSQLDatabaseWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1.PostgreSQLDatabase{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-database-account-operator-my-domain-v1-postgresqldatabase,mutating=true,failurePolicy=fail,sideEffects=None,groups=database-account-operator.my.domain,resources=postgresqldatabases,verbs=create,versions=v1,name=mpostgresqldatabase.kb.io,admissionReviewVersions=v1
+
+// Default fills in the operator-wide encoding and locales, so the stored api resource reflects the created database.
+// It only runs on creation as those fields are immutable. The defaulted fields are listed in defaultedAnnotation so
+// an existing database with other ones can still be adopted.
+func (w *PostgreSQLDatabaseWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	db := obj.(*v1.PostgreSQLDatabase)
+	var fields []string
+	for _, field := range []struct {
+		name         string
+		value        *string
+		defaultValue string
+	}{
+		{"encoding", &db.Spec.Encoding, w.DefaultEncoding},
+		{"lc_collate", &db.Spec.LC_Collate, w.DefaultLCCollate},
+		{"lc_ctype", &db.Spec.LC_CType, w.DefaultLCCType},
+	} {
+		if *field.value == "" && field.defaultValue != "" {
+			*field.value = field.defaultValue
+			fields = append(fields, field.name)
+		}
+	}
+	if len(fields) > 0 {
+		annotations := db.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[defaultedAnnotation] = strings.Join(fields, ",")
+		db.SetAnnotations(annotations)
+	}
+	return nil
+}
+
+// defaulted tells whether the field of the api resource was filled in by the defaulting webhook
+func defaulted(dbApiResource *v1.PostgreSQLDatabase, field string) bool {
+	return containsString(strings.Split(dbApiResource.GetAnnotations()[defaultedAnnotation], ","), field)
+}
+
+//+kubebuilder:webhook:path=/validate-database-account-operator-my-domain-v1-postgresqldatabase,mutating=false,failurePolicy=fail,sideEffects=None,groups=database-account-operator.my.domain,resources=postgresqldatabases,verbs=create;update,versions=v1,name=vpostgresqldatabase.kb.io,admissionReviewVersions=v1
+
+// ValidateCreate rejects an invalid spec
+func (w *PostgreSQLDatabaseWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return validateDatabase(&obj.(*v1.PostgreSQLDatabase).Spec)
+}
+
+// ValidateUpdate rejects an invalid spec and changes to the fields fixed when the database is created
+func (w *PostgreSQLDatabaseWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldDB, newDB := oldObj.(*v1.PostgreSQLDatabase), newObj.(*v1.PostgreSQLDatabase)
+	// the finalizer must be removable whatever the spec is
+	if !newDB.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err := validateDatabase(&newDB.Spec); err != nil {
+		return err
+	}
+	for field, values := range map[string][2]string{
+		"database":   {oldDB.Spec.Database, newDB.Spec.Database},
+		"encoding":   {oldDB.Spec.Encoding, newDB.Spec.Encoding},
+		"lc_collate": {oldDB.Spec.LC_Collate, newDB.Spec.LC_Collate},
+		"lc_ctype":   {oldDB.Spec.LC_CType, newDB.Spec.LC_CType},
+	} {
+		if values[0] != values[1] {
+			return fmt.Errorf(`%s is immutable, it cannot change from %s to %s`, field, values[0], values[1])
+		}
+	}
+	return nil
+}
+
+// ValidateDelete allows every deletion
+func (w *PostgreSQLDatabaseWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
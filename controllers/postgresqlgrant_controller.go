@@ -19,35 +19,93 @@ package controllers
 import (
 	"context"
 	v1 "database-account-operator/api/v1"
+	"database-account-operator/pkg/pgsql"
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // PostgreSQLGrantReconciler reconciles a PostgreSQLGrant object
 type PostgreSQLGrantReconciler struct {
 	client.Client
-	Scheme        *runtime.Scheme
-	DBClients     *map[string]*sql.DB
-	previousGrant *v1.PostgreSQLGrantSpec
+	Scheme      *runtime.Scheme
+	Connections *ConnectionRegistry
+	Recorder    record.EventRecorder
+	// ResyncInterval is how often the grants are checked for drift, zero disabling the checks
+	ResyncInterval    time.Duration
+	dependencyBackoff workqueue.RateLimiter
 }
 
+// grantToField indexes the PostgreSQLGrants by the role they grant the privileges to
+const grantToField = ".spec.to"
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *PostgreSQLGrantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.dependencyBackoff = newDependencyBackoff()
+	indexer := mgr.GetFieldIndexer()
+	if err := indexer.IndexField(context.Background(), &v1.PostgreSQLGrant{}, databaseNameField, func(object client.Object) []string {
+		return []string{object.(*v1.PostgreSQLGrant).Spec.PostgreSQLDatabaseName}
+	}); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(context.Background(), &v1.PostgreSQLGrant{}, grantToField, func(object client.Object) []string {
+		return []string{object.(*v1.PostgreSQLGrant).Spec.To}
+	}); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1.PostgreSQLGrant{}).
+		For(&v1.PostgreSQLGrant{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &v1.PostgreSQLDatabase{}}, handler.EnqueueRequestsFromMapFunc(r.findGrantsForDatabase),
+			builder.WithPredicates(becameReady)).
+		Watches(&source.Kind{Type: &v1.PostgreSQLAccount{}}, handler.EnqueueRequestsFromMapFunc(r.findGrantsForAccount),
+			builder.WithPredicates(becameReady)).
 		Complete(r)
 }
 
+// findGrantsForDatabase maps a PostgreSQLDatabase to the PostgreSQLGrants referencing it,
+// so they are retried once the database is ready
+func (r *PostgreSQLGrantReconciler) findGrantsForDatabase(db client.Object) []reconcile.Request {
+	return r.findGrants(db.GetNamespace(), client.MatchingFields{databaseNameField: db.GetName()})
+}
+
+// findGrantsForAccount maps a PostgreSQLAccount to the PostgreSQLGrants granting privileges to its role,
+// so they are retried once the role exists
+func (r *PostgreSQLGrantReconciler) findGrantsForAccount(account client.Object) []reconcile.Request {
+	return r.findGrants(account.GetNamespace(), client.MatchingFields{grantToField: account.(*v1.PostgreSQLAccount).Spec.Name})
+}
+
+func (r *PostgreSQLGrantReconciler) findGrants(namespace string, fields client.MatchingFields) []reconcile.Request {
+	grantList := &v1.PostgreSQLGrantList{}
+	if err := r.List(context.Background(), grantList, client.InNamespace(namespace), fields); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, grant := range grantList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: grant.Name, Namespace: grant.Namespace}})
+	}
+	return requests
+}
+
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlgrants,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlgrants/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlgrants/finalizers,verbs=update
+//+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqldatabases,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -58,51 +116,77 @@ func (r *PostgreSQLGrantReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	grantApiResource := &v1.PostgreSQLGrant{}
 
-	r.Get(ctx, types.NamespacedName{Name: req.Name, Namespace: req.Namespace}, grantApiResource)
+	if err := r.Get(ctx, types.NamespacedName{Name: req.Name, Namespace: req.Namespace}, grantApiResource); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !grantApiResource.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalizeGrant(ctx, grantApiResource)
+	}
+	if !controllerutil.ContainsFinalizer(grantApiResource, finalizerName) {
+		controllerutil.AddFinalizer(grantApiResource, finalizerName)
+		if err := r.Update(ctx, grantApiResource); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
 	grantSpec := grantApiResource.Spec
-	grantStatus := grantApiResource.Status
 	dbNamespacedName := types.NamespacedName{Name: grantSpec.PostgreSQLDatabaseName, Namespace: req.Namespace}
+	wasInSync := inSync(&grantApiResource.Status.ReconcileStatus, grantApiResource.Generation)
 
 	var e error
+	var drifts []string
 	if err := validateGrantSpec(&grantSpec); err != nil {
-		e = err
-	} else if (*r.DBClients)[dbNamespacedName.String()] == nil {
-
-		e = fmt.Errorf("unable to find db client for PostgreSQLDatabase, is there a PostgreSQLDatabase api resource with name %s in ready status?", dbNamespacedName.String())
-	} else if err = r.upsertSchema(&dbNamespacedName, grantSpec.Schema); err != nil {
-		e = err
-	} else if err = r.upsertGrant(&dbNamespacedName, &grantSpec); err != nil {
-		e = err
+		e = invalidSpecError(err)
+	} else if db, release, err := acquireDatabase(ctx, r.Client, r.Connections, &dbNamespacedName); err != nil {
+		e = connectionError(err)
 	} else {
-		r.previousGrant = &grantSpec
+		defer release()
+		var grantDrifts, kept []string
+		if drifts, err = r.upsertSchema(db, &grantSpec); err != nil {
+			e = err
+		} else if exists, err := roleExists(db, grantSpec.To); err != nil {
+			e = err
+		} else if !exists {
+			e = dependencyError(fmt.Errorf(`role %s does not exist`, grantSpec.To))
+		} else if kept, err = r.siblingPrivileges(ctx, grantApiResource); err != nil {
+			e = err
+		} else if grantDrifts, err = r.upsertGrant(db, &grantSpec, kept); err != nil {
+			e = err
+		} else {
+			drifts = append(drifts, grantDrifts...)
+		}
 	}
 
-	grantStatus.Ready = e == nil
-	if e != nil {
-		grantStatus.Error = e.Error()
-	} else {
-		grantStatus.Error = ""
+	setReconcileStatus(&grantApiResource.Status.ReconcileStatus, grantApiResource.Generation, e)
+	if e == nil {
+		if !wasInSync && !observed(grantSpec.ManagementPolicy) {
+			drifts = nil
+		}
+		recordDrift(r.Recorder, grantApiResource, &grantApiResource.Status.ReconcileStatus, drifts, observed(grantSpec.ManagementPolicy))
 	}
 	r.Status().Update(ctx, grantApiResource)
-	log.FromContext(ctx).Info("Reconciled", "req", req, "grant", grantSpec, "status", grantStatus)
-	return ctrl.Result{}, e
+	log.FromContext(ctx).Info("Reconciled", "req", req, "grant", grantSpec, "status", grantApiResource.Status)
+	return reconcileResult(r.dependencyBackoff, r.ResyncInterval, req, e)
 }
 
-func (r *PostgreSQLGrantReconciler) upsertSchema(dbNamespacedName *types.NamespacedName, schema string) error {
-	exists, err := r.schemaExists(dbNamespacedName, schema)
+// upsertSchema creates the schema when it does not exist, returning it as a drift
+func (r *PostgreSQLGrantReconciler) upsertSchema(db *sql.DB, grantSpec *v1.PostgreSQLGrantSpec) ([]string, error) {
+	exists, err := r.schemaExists(db, grantSpec.Schema)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !exists {
-		return r.createSchema(dbNamespacedName, schema)
+	if exists {
+		return nil, nil
 	}
-	return nil
+	if observed(grantSpec.ManagementPolicy) {
+		return []string{fmt.Sprintf(`schema %s does not exist`, grantSpec.Schema)}, nil
+	}
+	return []string{fmt.Sprintf(`schema %s did not exist`, grantSpec.Schema)}, r.createSchema(db, grantSpec.Schema)
 }
 
-func (r *PostgreSQLGrantReconciler) schemaExists(dbNamespacedName *types.NamespacedName, schema string) (bool, error) {
+func (r *PostgreSQLGrantReconciler) schemaExists(db *sql.DB, schema string) (bool, error) {
 	query := `SELECT schema_name FROM information_schema.schemata WHERE schema_name = $1;`
-	rows, err := (*r.DBClients)[dbNamespacedName.String()].Query(query, strings.ToLower(schema))
+	rows, err := db.Query(query, strings.ToLower(schema))
 	if err != nil {
 		return false, fmt.Errorf(`error executing query %s for schema %s : %w`, query, schema, err)
 	}
@@ -119,13 +203,13 @@ func (r *PostgreSQLGrantReconciler) schemaExists(dbNamespacedName *types.Namespa
 	if err != nil {
 		return false, fmt.Errorf(`error reading configuration from db for schema %s : %w`, schema, err)
 	}
-	return result == schema, nil
+	return result == strings.ToLower(schema), nil
 }
 
-func (r *PostgreSQLGrantReconciler) createSchema(dbNamespacedName *types.NamespacedName, schema string) error {
-	query := fmt.Sprintf(`CREATE SCHEMA %s`, strings.ToLower(schema))
+func (r *PostgreSQLGrantReconciler) createSchema(db *sql.DB, schema string) error {
+	query := fmt.Sprintf(`CREATE SCHEMA %s`, pgsql.QuoteIdent(strings.ToLower(schema)))
 
-	rows, err := (*r.DBClients)[dbNamespacedName.String()].Query(query)
+	rows, err := db.Query(query)
 	if err != nil {
 		return fmt.Errorf(`error executing query %s for schema %s : %w`, query, schema, err)
 	}
@@ -133,77 +217,195 @@ func (r *PostgreSQLGrantReconciler) createSchema(dbNamespacedName *types.Namespa
 	return nil
 }
 
-func (r *PostgreSQLGrantReconciler) upsertGrant(dbNamespacedName *types.NamespacedName, grantSpec *v1.PostgreSQLGrantSpec) error {
-	tExists, err := r.tablesExists(dbNamespacedName, grantSpec.Schema)
-	if err != nil {
+// finalizeGrant revokes the granted privileges and then releases the finalizer
+func (r *PostgreSQLGrantReconciler) finalizeGrant(ctx context.Context, grantApiResource *v1.PostgreSQLGrant) error {
+	if !controllerutil.ContainsFinalizer(grantApiResource, finalizerName) {
+		return nil
+	}
+	grantSpec := &grantApiResource.Spec
+	// an invalid spec was never granted, so there is nothing to revoke
+	if !observed(grantSpec.ManagementPolicy) && validateGrantSpec(grantSpec) == nil {
+		dbNamespacedName := types.NamespacedName{Name: grantSpec.PostgreSQLDatabaseName, Namespace: grantApiResource.Namespace}
+		kept, err := r.siblingPrivileges(ctx, grantApiResource)
+		if err != nil {
+			return err
+		}
+		db, release, err := acquireDatabase(ctx, r.Client, r.Connections, &dbNamespacedName)
+		if err != nil {
+			return err
+		}
+		err = r.revokeAll(db, grantSpec, kept)
+		release()
+		if err != nil {
+			return err
+		}
+	}
+	controllerutil.RemoveFinalizer(grantApiResource, finalizerName)
+	return r.Update(ctx, grantApiResource)
+}
+
+// revokeAll revokes the privileges of the grant except the kept ones, skipping it when the schema or the role are
+// already gone
+func (r *PostgreSQLGrantReconciler) revokeAll(db *sql.DB, grantSpec *v1.PostgreSQLGrantSpec, kept []string) error {
+	exists, err := r.schemaExists(db, grantSpec.Schema)
+	if err != nil || !exists {
 		return err
 	}
-	if !tExists {
+	exists, err = roleExists(db, grantSpec.To)
+	if err != nil || !exists {
+		return err
+	}
+	var revoked []string
+	for _, privilege := range desiredPrivileges(grantSpec.Type) {
+		if !containsString(kept, privilege) {
+			revoked = append(revoked, privilege)
+		}
+	}
+	if len(revoked) == 0 {
 		return nil
 	}
-	gExists, err := r.grantExists(dbNamespacedName, grantSpec)
-	if err != nil {
-		return err
+	return r.revokeGrant(db, grantSpec, revoked)
+}
+
+// siblingPrivileges returns the privileges wanted by the other PostgreSQLGrants of the same role, database and
+// schema, which the grant must neither revoke as unexpected nor on deletion
+func (r *PostgreSQLGrantReconciler) siblingPrivileges(ctx context.Context, grant *v1.PostgreSQLGrant) ([]string, error) {
+	grantList := &v1.PostgreSQLGrantList{}
+	if err := r.List(ctx, grantList, client.InNamespace(grant.Namespace), client.MatchingFields{grantToField: grant.Spec.To}); err != nil {
+		return nil, fmt.Errorf(`error listing PostgreSQLGrants to %s : %w`, grant.Spec.To, err)
 	}
-	if !gExists {
-		return r.createGrant(dbNamespacedName, grantSpec)
+	var privileges []string
+	for _, sibling := range grantList.Items {
+		if sibling.UID == grant.UID || !sibling.DeletionTimestamp.IsZero() ||
+			sibling.Spec.PostgreSQLDatabaseName != grant.Spec.PostgreSQLDatabaseName ||
+			!strings.EqualFold(sibling.Spec.Schema, grant.Spec.Schema) || validateGrantSpec(&sibling.Spec) != nil {
+			continue
+		}
+		for _, privilege := range desiredPrivileges(sibling.Spec.Type) {
+			if !containsString(privileges, privilege) {
+				privileges = append(privileges, privilege)
+			}
+		}
 	}
-	return nil
+	return privileges, nil
+}
+
+func desiredPrivileges(types []string) []string {
+	var privileges []string
+	for _, t := range types {
+		if strings.ToUpper(t) == "ALL" {
+			return pgsql.TablePrivileges
+		}
+		privileges = append(privileges, strings.ToUpper(t))
+	}
+	return privileges
 }
 
-func (r *PostgreSQLGrantReconciler) grantExists(dbNamespacedName *types.NamespacedName, grantSpec *v1.PostgreSQLGrantSpec) (bool, error) {
-	query := `SELECT grantee, privilege_type FROM information_schema.role_table_grants WHERE table_schema=$1;`
-	rows, err := (*r.DBClients)[dbNamespacedName.String()].Query(query, strings.ToLower(grantSpec.Schema))
+// upsertGrant converges the privileges of the grantee on the tables of the schema, granting the missing
+// privileges and revoking the ones neither in the spec nor kept for other grants. It returns those differences
+// as drifts, and only compares the privileges when the grant is observed.
+func (r *PostgreSQLGrantReconciler) upsertGrant(db *sql.DB, grantSpec *v1.PostgreSQLGrantSpec, kept []string) ([]string, error) {
+	tables, err := r.countTables(db, grantSpec.Schema)
 	if err != nil {
-		return false, fmt.Errorf(`error executing query %s for grant %+v : %w`, query, grantSpec, err)
+		return nil, err
 	}
-	defer rows.Close()
-	if !rows.Next() {
-		return false, nil
+	if tables == 0 {
+		// an observed schema without tables has no privileges to compare
+		if observed(grantSpec.ManagementPolicy) {
+			return nil, nil
+		}
+		return nil, dependencyError(fmt.Errorf(`schema %s has no tables yet`, grantSpec.Schema))
 	}
-	err = rows.Err()
+	held, granted, err := r.readGrants(db, grantSpec)
 	if err != nil {
-		return false, fmt.Errorf(`error iterating configuration from db for grant %+v : %w`, grantSpec, err)
+		return nil, err
 	}
-	var result string
-	err = rows.Scan(&result)
-	if err != nil {
-		return false, fmt.Errorf(`error reading configuration from db for grant %+v : %w`, grantSpec, err)
+	desired := desiredPrivileges(grantSpec.Type)
+	var missing, extra []string
+	for _, privilege := range desired {
+		if held[privilege] < tables {
+			missing = append(missing, privilege)
+		}
 	}
-	return result != "", nil
+	// the privileges the grantee holds as the owner of a table are not revoked
+	for _, privilege := range pgsql.TablePrivileges {
+		if granted[privilege] > 0 && !containsString(desired, privilege) && !containsString(kept, privilege) {
+			extra = append(extra, privilege)
+		}
+	}
+	var drifts []string
+	if len(missing) > 0 {
+		drifts = append(drifts, fmt.Sprintf(`%s privileges were missing for %s in schema %s`, strings.Join(missing, ", "), grantSpec.To, grantSpec.Schema))
+	}
+	if len(extra) > 0 {
+		drifts = append(drifts, fmt.Sprintf(`%s privileges were not expected for %s in schema %s`, strings.Join(extra, ", "), grantSpec.To, grantSpec.Schema))
+	}
+	if observed(grantSpec.ManagementPolicy) {
+		return drifts, nil
+	}
+	if len(missing) > 0 {
+		if err = r.createGrant(db, grantSpec, missing); err != nil {
+			return nil, err
+		}
+	}
+	if len(extra) > 0 {
+		if err = r.revokeGrant(db, grantSpec, extra); err != nil {
+			return nil, err
+		}
+	}
+	return drifts, nil
 }
 
-func (r *PostgreSQLGrantReconciler) tablesExists(dbNamespacedName *types.NamespacedName, schema string) (bool, error) {
-
-	query := `SELECT table_schema FROM information_schema.tables WHERE table_schema = $1;`
-	rows, err := (*r.DBClients)[dbNamespacedName.String()].Query(query, strings.ToLower(schema))
+// readGrants returns, for each privilege the grantee holds in the schema, the number of tables it holds it on, and
+// the number of tables it was granted it on by another role, leaving out the privileges it holds as their owner
+func (r *PostgreSQLGrantReconciler) readGrants(db *sql.DB, grantSpec *v1.PostgreSQLGrantSpec) (held, granted map[string]int, err error) {
+	query := `SELECT privilege_type, count(DISTINCT table_name), count(DISTINCT table_name) FILTER (WHERE grantor <> grantee)
+		FROM information_schema.role_table_grants WHERE table_schema = $1 AND grantee = $2 GROUP BY privilege_type;`
+	rows, err := db.Query(query, strings.ToLower(grantSpec.Schema), grantSpec.To)
 	if err != nil {
-		return false, fmt.Errorf(`error executing query %s for schema %s : %w`, query, schema, err)
+		return nil, nil, fmt.Errorf(`error executing query %s for grant %+v : %w`, query, grantSpec, err)
 	}
 	defer rows.Close()
-	if !rows.Next() {
-		return false, nil
+	held, granted = map[string]int{}, map[string]int{}
+	for rows.Next() {
+		var privilege string
+		var heldTables, grantedTables int
+		if err = rows.Scan(&privilege, &heldTables, &grantedTables); err != nil {
+			return nil, nil, fmt.Errorf(`error reading configuration from db for grant %+v : %w`, grantSpec, err)
+		}
+		held[privilege], granted[privilege] = heldTables, grantedTables
 	}
-	err = rows.Err()
-	if err != nil {
-		return false, fmt.Errorf(`error iterating configuration from db for schema %s : %w`, schema, err)
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf(`error iterating configuration from db for grant %+v : %w`, grantSpec, err)
 	}
-	var result string
-	err = rows.Scan(&result)
+	return held, granted, nil
+}
+
+func (r *PostgreSQLGrantReconciler) countTables(db *sql.DB, schema string) (int, error) {
+	query := `SELECT count(*) FROM information_schema.tables WHERE table_schema = $1;`
+	var result int
+	err := db.QueryRow(query, strings.ToLower(schema)).Scan(&result)
 	if err != nil {
-		return false, fmt.Errorf(`error reading configuration from db for schema %s : %w`, schema, err)
+		return 0, fmt.Errorf(`error executing query %s for schema %s : %w`, query, schema, err)
 	}
-	return result != "", nil
+	return result, nil
+}
 
+func (r *PostgreSQLGrantReconciler) createGrant(db *sql.DB, grantSpec *v1.PostgreSQLGrantSpec, privileges []string) error {
+	query := fmt.Sprintf(`GRANT %s ON ALL TABLES IN SCHEMA %s TO %s`,
+		strings.Join(privileges, ", "), pgsql.QuoteIdent(strings.ToLower(grantSpec.Schema)), pgsql.QuoteIdent(grantSpec.To))
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf(`error executing query %s for grant %+v : %w`, query, grantSpec, err)
+	}
+	rows.Close()
+	return nil
 }
 
-func (r *PostgreSQLGrantReconciler) createGrant(dbNamespacedName *types.NamespacedName, grantSpec *v1.PostgreSQLGrantSpec) error {
-	query := `GRANT $1 ON ALL TABLES IN SCHEMA $2 TO $3`
-	rows, err := (*r.DBClients)[dbNamespacedName.String()].Query(
-		query,
-		strings.Join(grantSpec.Type[:], ","),
-		strings.ToLower(grantSpec.Schema),
-	)
+func (r *PostgreSQLGrantReconciler) revokeGrant(db *sql.DB, grantSpec *v1.PostgreSQLGrantSpec, privileges []string) error {
+	query := fmt.Sprintf(`REVOKE %s ON ALL TABLES IN SCHEMA %s FROM %s`,
+		strings.Join(privileges, ", "), pgsql.QuoteIdent(strings.ToLower(grantSpec.Schema)), pgsql.QuoteIdent(grantSpec.To))
+	rows, err := db.Query(query)
 	if err != nil {
 		return fmt.Errorf(`error executing query %s for grant %+v : %w`, query, grantSpec, err)
 	}
@@ -211,12 +413,23 @@ func (r *PostgreSQLGrantReconciler) createGrant(dbNamespacedName *types.Namespac
 	return nil
 }
 
-func validateGrantSpec(spec *v1.PostgreSQLGrantSpec) error {
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
-	if !validPostgresName(spec.Schema) {
+func validateGrantSpec(spec *v1.PostgreSQLGrantSpec) error {
+	if spec.PostgreSQLDatabaseName == "" {
+		return fmt.Errorf(`postgreSQLDatabaseName is required`)
+	}
+	if !pgsql.ValidName(spec.Schema) {
 		return fmt.Errorf(`invalid schema %s`, spec.Schema)
 	}
-	if !validPostgresName(spec.To) {
+	if !pgsql.ValidName(spec.To) {
 		return fmt.Errorf(`invalid to %s`, spec.To)
 	}
 	if !validGrantType(spec.Type) {
@@ -226,6 +439,9 @@ func validateGrantSpec(spec *v1.PostgreSQLGrantSpec) error {
 }
 
 func validGrantType(types []string) bool {
+	if len(types) == 0 {
+		return false
+	}
 	for _, t := range types {
 		switch strings.ToLower(t) {
 		case "select", "insert", "update", "delete", "truncate":
@@ -0,0 +1,76 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v1 "database-account-operator/api/v1"
+)
+
+// PostgreSQLGrantWebhook validates PostgreSQLGrant api resources on admission
+type PostgreSQLGrantWebhook struct{}
+
+var _ admission.CustomValidator = &PostgreSQLGrantWebhook{}
+
+// SetupWebhookWithManager sets up the webhook with the Manager.
+func (w *PostgreSQLGrantWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1.PostgreSQLGrant{}).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-database-account-operator-my-domain-v1-postgresqlgrant,mutating=false,failurePolicy=fail,sideEffects=None,groups=database-account-operator.my.domain,resources=postgresqlgrants,verbs=create;update,versions=v1,name=vpostgresqlgrant.kb.io,admissionReviewVersions=v1
+
+// ValidateCreate rejects an invalid spec
+func (w *PostgreSQLGrantWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return validateGrantSpec(&obj.(*v1.PostgreSQLGrant).Spec)
+}
+
+// ValidateUpdate rejects an invalid spec and changes to what the privileges are granted on and to.
+// Only the privilege types can be updated, the others would leave the previous grant behind.
+func (w *PostgreSQLGrantWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldGrant, newGrant := oldObj.(*v1.PostgreSQLGrant), newObj.(*v1.PostgreSQLGrant)
+	// the finalizer must be removable whatever the spec is
+	if !newGrant.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err := validateGrantSpec(&newGrant.Spec); err != nil {
+		return err
+	}
+	for field, values := range map[string][2]string{
+		"postgreSQLDatabaseName": {oldGrant.Spec.PostgreSQLDatabaseName, newGrant.Spec.PostgreSQLDatabaseName},
+		"to":                     {oldGrant.Spec.To, newGrant.Spec.To},
+		"schema":                 {oldGrant.Spec.Schema, newGrant.Spec.Schema},
+	} {
+		if values[0] != values[1] {
+			return fmt.Errorf(`%s is immutable, it cannot change from %s to %s`, field, values[0], values[1])
+		}
+	}
+	return nil
+}
+
+// ValidateDelete allows every deletion
+func (w *PostgreSQLGrantWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
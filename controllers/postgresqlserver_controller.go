@@ -0,0 +1,187 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	v1 "database-account-operator/api/v1"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// PostgreSQLServerReconciler reconciles a PostgreSQLServer object
+type PostgreSQLServerReconciler struct {
+	client.Client
+	Scheme      *runtime.Scheme
+	Connections *ConnectionRegistry
+	// ResyncInterval is how often the admin connections are checked, zero disabling the checks
+	ResyncInterval time.Duration
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PostgreSQLServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.PostgreSQLServer{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findServersForSecret)).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.findServersForConfigMap)).
+		Complete(r)
+}
+
+// findServersForSecret maps a Secret to the PostgreSQLServers reading their credentials or certificates from it,
+// so a rotated admin password or certificate reopens the connection
+func (r *PostgreSQLServerReconciler) findServersForSecret(secret client.Object) []reconcile.Request {
+	serverList := &v1.PostgreSQLServerList{}
+	if err := r.List(context.Background(), serverList); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, server := range serverList.Items {
+		ref := server.Spec.CredentialsSecretRef
+		if ref.Namespace != secret.GetNamespace() {
+			continue
+		}
+		if ref.Name == secret.GetName() || tlsReferencesSecret(server.Spec.TLS, secret.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: server.Name}})
+		}
+	}
+	return requests
+}
+
+// findServersForConfigMap maps a ConfigMap to the PostgreSQLServers reading their CA bundle from it
+func (r *PostgreSQLServerReconciler) findServersForConfigMap(configMap client.Object) []reconcile.Request {
+	serverList := &v1.PostgreSQLServerList{}
+	if err := r.List(context.Background(), serverList); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, server := range serverList.Items {
+		if server.Spec.CredentialsSecretRef.Namespace == configMap.GetNamespace() && tlsReferencesConfigMap(server.Spec.TLS, configMap.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: server.Name}})
+		}
+	}
+	return requests
+}
+
+//+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlservers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlservers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=database-account-operator.my.domain,resources=postgresqlservers/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.2/pkg/reconcile
+func (r *PostgreSQLServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	server := &v1.PostgreSQLServer{}
+	if err := r.Get(ctx, req.NamespacedName, server); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !server.DeletionTimestamp.IsZero() {
+		return r.finalizeServer(ctx, server)
+	}
+	if !controllerutil.ContainsFinalizer(server, finalizerName) {
+		controllerutil.AddFinalizer(server, finalizerName)
+		if err := r.Update(ctx, server); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var e error
+	if err := validateServer(&server.Spec); err != nil {
+		e = invalidSpecError(err)
+	} else if err := r.dbOpen(ctx, server); err != nil {
+		e = connectionError(err)
+	}
+
+	setReconcileStatus(&server.Status.ReconcileStatus, server.Generation, e)
+	r.Status().Update(ctx, server)
+	log.FromContext(ctx).Info("Reconciled", "req", req, "serverStatus", server.Status)
+	if e != nil {
+		return ctrl.Result{}, e
+	}
+	return ctrl.Result{RequeueAfter: r.ResyncInterval}, nil
+}
+
+// finalizeServer closes and evicts the admin connection of the server once no PostgreSQLDatabase uses it
+func (r *PostgreSQLServerReconciler) finalizeServer(ctx context.Context, server *v1.PostgreSQLServer) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(server, finalizerName) {
+		return ctrl.Result{}, nil
+	}
+	dbList := &v1.PostgreSQLDatabaseList{}
+	if err := r.List(ctx, dbList); err != nil {
+		return ctrl.Result{}, err
+	}
+	var dependents []string
+	for _, db := range dbList.Items {
+		if db.Spec.ServerRef == server.Name {
+			dependents = append(dependents, "PostgreSQLDatabase "+db.Namespace+"/"+db.Name)
+		}
+	}
+	if len(dependents) > 0 {
+		e := &reconcileError{
+			reason: reasonDependentsExist,
+			err:    fmt.Errorf("server is still referenced by %s", strings.Join(dependents, ", ")),
+		}
+		setReconcileStatus(&server.Status.ReconcileStatus, server.Generation, e)
+		r.Status().Update(ctx, server)
+		return ctrl.Result{RequeueAfter: dependentsRequeueDelay}, nil
+	}
+
+	r.Connections.Evict(serverConnectionKey(server.Name))
+	controllerutil.RemoveFinalizer(server, finalizerName)
+	return ctrl.Result{}, r.Update(ctx, server)
+}
+
+// dbOpen opens the admin connection of the server, reopening it when the address, the credentials or the certificates changed
+func (r *PostgreSQLServerReconciler) dbOpen(ctx context.Context, server *v1.PostgreSQLServer) error {
+	conn, err := resolveServerConnection(ctx, r.Client, server)
+	if err != nil {
+		return err
+	}
+	key := serverConnectionKey(server.Name)
+	if err = r.Connections.Open(key, conn.connectionString("")); err != nil {
+		return err
+	}
+	return r.Connections.Ping(ctx, key)
+}
+
+func validateServer(serverSpec *v1.PostgreSQLServerSpec) error {
+	if !validAddress(serverSpec.Address) {
+		return fmt.Errorf(`invalid address %s`, serverSpec.Address)
+	}
+	ref := serverSpec.CredentialsSecretRef
+	if ref.Name == "" || ref.Namespace == "" {
+		return fmt.Errorf(`credentialsSecretRef.name and credentialsSecretRef.namespace are required`)
+	}
+	return validateTLS(serverSpec.TLS)
+}
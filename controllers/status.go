@@ -0,0 +1,208 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1 "database-account-operator/api/v1"
+)
+
+// Reasons reported in the status conditions
+const (
+	reasonReconciled         = "Reconciled"
+	reasonInvalidSpec        = "InvalidSpec"
+	reasonConnectionFailed   = "ConnectionFailed"
+	reasonSyncFailed         = "SyncFailed"
+	reasonDependentsExist    = "DependentsExist"
+	reasonDependencyNotReady = "DependencyNotReady"
+	reasonInSync             = "InSync"
+	reasonDriftCorrected     = "DriftCorrected"
+	reasonDriftDetected      = "DriftDetected"
+	reasonAccountDisabled    = "AccountDisabled"
+	reasonAccountEnabled     = "AccountEnabled"
+	reasonSessionsTerminated = "SessionsTerminated"
+)
+
+// reconcileError tags an error with the reason reported in the status conditions
+type reconcileError struct {
+	reason string
+	err    error
+}
+
+func (e *reconcileError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reconcileError) Unwrap() error {
+	return e.err
+}
+
+func invalidSpecError(err error) error {
+	return &reconcileError{reason: reasonInvalidSpec, err: err}
+}
+
+// connectionError tags err as a connection failure unless it already has a more specific reason
+func connectionError(err error) error {
+	var re *reconcileError
+	if errors.As(err, &re) {
+		return err
+	}
+	return &reconcileError{reason: reasonConnectionFailed, err: err}
+}
+
+// dependencyError tags err as caused by an object the resource depends on not existing or not being ready yet
+func dependencyError(err error) error {
+	return &reconcileError{reason: reasonDependencyNotReady, err: err}
+}
+
+// newDependencyBackoff returns the rate limiter spacing the retries of the resources waiting for a dependency
+func newDependencyBackoff() workqueue.RateLimiter {
+	return workqueue.NewItemExponentialFailureRateLimiter(5*time.Second, 5*time.Minute)
+}
+
+// reconcileResult retries the resources waiting for a dependency with an increasing delay instead of reporting
+// an error, which the watches on the dependencies usually make unnecessary. Other errors are returned as they are,
+// and the reconciled resources are resynced after resyncInterval, zero disabling the resync.
+func reconcileResult(backoff workqueue.RateLimiter, resyncInterval time.Duration, req ctrl.Request, e error) (ctrl.Result, error) {
+	var re *reconcileError
+	if errors.As(e, &re) && re.reason == reasonDependencyNotReady {
+		return ctrl.Result{RequeueAfter: backoff.When(req)}, nil
+	}
+	backoff.Forget(req)
+	if e != nil {
+		return ctrl.Result{}, e
+	}
+	return ctrl.Result{RequeueAfter: resyncInterval}, nil
+}
+
+// becameReady passes the events of the api resources whose Ready condition turned True, including the creation
+// events replayed for every existing resource when the operator starts
+var becameReady = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return resourceReady(e.Object)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !resourceReady(e.ObjectOld) && resourceReady(e.ObjectNew)
+	},
+	DeleteFunc: func(event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(event.GenericEvent) bool {
+		return false
+	},
+}
+
+func resourceReady(object client.Object) bool {
+	switch resource := object.(type) {
+	case *v1.PostgreSQLDatabase:
+		return meta.IsStatusConditionTrue(resource.Status.Conditions, v1.ConditionReady)
+	case *v1.PostgreSQLAccount:
+		return meta.IsStatusConditionTrue(resource.Status.Conditions, v1.ConditionReady)
+	}
+	return false
+}
+
+// inSync tells whether the given generation was already applied by a previous reconcile, in which case any change
+// the reconcile has to make on the PostgreSQL object is a drift rather than the spec being applied
+func inSync(status *v1.ReconcileStatus, generation int64) bool {
+	return status.ObservedGeneration == generation && meta.IsStatusConditionTrue(status.Conditions, v1.ConditionReady)
+}
+
+// observed tells whether the PostgreSQL object must be left untouched, only reporting its differences with the spec
+func observed(policy v1.ManagementPolicy) bool {
+	return policy == v1.ManagementPolicyObserve
+}
+
+// recordDrift sets the Drifted condition after a successful reconcile and emits a Warning Event for every drift
+// it corrected, or only detected when observe is set
+func recordDrift(recorder record.EventRecorder, object client.Object, status *v1.ReconcileStatus, drifts []string, observe bool) {
+	condition := metav1.Condition{
+		Type:               v1.ConditionDrifted,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: object.GetGeneration(),
+		Reason:             reasonInSync,
+	}
+	if len(drifts) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = reasonDriftCorrected
+		if observe {
+			condition.Reason = reasonDriftDetected
+		}
+		condition.Message = strings.Join(drifts, ", ")
+		for _, drift := range drifts {
+			recorder.Event(object, corev1.EventTypeWarning, condition.Reason, drift)
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+// setReconcileStatus records the outcome of reconciling the given generation, e being nil on success
+func setReconcileStatus(status *v1.ReconcileStatus, generation int64, e error) {
+	reason, message := reasonReconciled, ""
+	if e != nil {
+		reason, message = reasonSyncFailed, e.Error()
+		var re *reconcileError
+		if errors.As(e, &re) {
+			reason = re.reason
+		}
+	}
+
+	connected, synced := metav1.ConditionTrue, metav1.ConditionTrue
+	switch reason {
+	case reasonInvalidSpec, reasonDependencyNotReady:
+		connected, synced = metav1.ConditionUnknown, metav1.ConditionFalse
+	case reasonConnectionFailed:
+		connected, synced = metav1.ConditionFalse, metav1.ConditionFalse
+	case reasonReconciled:
+	default:
+		synced = metav1.ConditionFalse
+	}
+	ready, degraded := metav1.ConditionTrue, metav1.ConditionFalse
+	if e != nil {
+		ready, degraded = metav1.ConditionFalse, metav1.ConditionTrue
+	}
+
+	for _, condition := range []metav1.Condition{
+		{Type: v1.ConditionReady, Status: ready},
+		{Type: v1.ConditionConnected, Status: connected},
+		{Type: v1.ConditionSynced, Status: synced},
+		{Type: v1.ConditionDegraded, Status: degraded},
+	} {
+		condition.ObservedGeneration = generation
+		condition.Reason = reason
+		condition.Message = message
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+	status.ObservedGeneration = generation
+	if e == nil {
+		now := metav1.Now()
+		status.LastSyncTime = &now
+	}
+}
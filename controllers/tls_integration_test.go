@@ -0,0 +1,147 @@
+//go:build integration
+// +build integration
+
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "database-account-operator/api/v1"
+)
+
+// TestTLSConnections starts the postgres of hack/tls-postgres.sh, which only accepts TLS connections with a client
+// certificate, and connects to it through readTLS and connectionString with every verifying sslMode.
+// It needs docker and openssl.
+func TestTLSConnections(t *testing.T) {
+	for _, tool := range []string{"docker", "openssl"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is required : %v", tool, err)
+		}
+	}
+	dir := t.TempDir()
+	port := "5433"
+	if p := os.Getenv("PORT"); p != "" {
+		port = p
+	}
+	container := fmt.Sprintf("database-account-operator-tls-test-%d", time.Now().UnixNano())
+	script := exec.Command("../hack/tls-postgres.sh")
+	script.Env = append(os.Environ(), "DIR="+dir, "PORT="+port, "CONTAINER="+container, "CREATE_RESOURCES=false")
+	if out, err := script.CombinedOutput(); err != nil {
+		t.Fatalf("hack/tls-postgres.sh failed : %v\n%s", err, out)
+	}
+	t.Cleanup(func() {
+		exec.Command("docker", "rm", "-f", container).Run()
+	})
+	// the server started by the entrypoint to initialize the data directory does not listen on TCP
+	deadline := time.Now().Add(time.Minute)
+	for exec.Command("docker", "exec", container, "pg_isready", "-h", "127.0.0.1", "-U", "postgres").Run() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("postgres did not become ready")
+		}
+		time.Sleep(time.Second)
+	}
+	wrongCA := exec.Command("openssl", "req", "-x509", "-new", "-nodes", "-newkey", "rsa:2048", "-days", "1",
+		"-subj", "/CN=wrong-ca", "-keyout", filepath.Join(dir, "wrong-ca.key"), "-out", filepath.Join(dir, "wrong-ca.crt"))
+	if out, err := wrongCA.CombinedOutput(); err != nil {
+		t.Fatalf("error generating the wrong CA : %v\n%s", err, out)
+	}
+
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres-tls-ca", Namespace: "default"},
+			Data:       map[string]string{"ca.crt": readFile(t, dir, "ca.crt")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "wrong-ca", Namespace: "default"},
+			Data:       map[string]string{"ca.crt": readFile(t, dir, "wrong-ca.crt")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres-tls-client", Namespace: "default"},
+			Type:       corev1.SecretTypeTLS,
+			Data: map[string][]byte{
+				"tls.crt": []byte(readFile(t, dir, "client.crt")),
+				"tls.key": []byte(readFile(t, dir, "client.key")),
+			},
+		},
+	).Build()
+
+	tests := []struct {
+		name    string
+		sslMode v1.SSLMode
+		ca      string
+		fails   bool
+	}{
+		{"require", v1.SSLModeRequire, "", false},
+		{"verify-ca", v1.SSLModeVerifyCA, "postgres-tls-ca", false},
+		{"verify-full", v1.SSLModeVerifyFull, "postgres-tls-ca", false},
+		{"verify-ca with a wrong CA", v1.SSLModeVerifyCA, "wrong-ca", true},
+		{"verify-full with a wrong CA", v1.SSLModeVerifyFull, "wrong-ca", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &v1.TLSSpec{
+				SSLMode:             tt.sslMode,
+				ClientCertSecretRef: &v1.ClientCertSecretRef{Name: "postgres-tls-client"},
+			}
+			if tt.ca != "" {
+				spec.CA = &v1.CABundleSource{ConfigMapKeyRef: &v1.KeyRef{Name: tt.ca}}
+			}
+			params, err := readTLS(context.Background(), c, "default", spec)
+			if err != nil {
+				t.Fatalf("readTLS failed : %v", err)
+			}
+			conn := &serverConnection{user: "postgres", address: "localhost:" + port, tls: params}
+			err = ping(conn.connectionString("postgres"))
+			if tt.fails && err == nil {
+				t.Fatalf("connection with sslMode %s and CA %s succeeded", tt.sslMode, tt.ca)
+			}
+			if !tt.fails && err != nil {
+				t.Fatalf("connection with sslMode %s failed : %v", tt.sslMode, err)
+			}
+		})
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func ping(connectionString string) error {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Ping()
+}
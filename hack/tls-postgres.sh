@@ -0,0 +1,60 @@
+#!/usr/bin/env bash
+
+# Starts a local postgres only accepting TLS connections, to try the tls settings of PostgreSQLServer
+# and PostgreSQLDatabase. It generates a CA, a server certificate for localhost and a client certificate
+# for the postgres user, and creates the Secret and the ConfigMap the tls settings reference.
+#
+# Usage: hack/tls-postgres.sh [namespace]
+#
+# CREATE_RESOURCES=false skips the Secret and the ConfigMap, as the TLS integration test does:
+#   go test -tags integration ./controllers -run TLS
+
+set -euo pipefail
+
+NAMESPACE=${1:-default}
+CONTAINER=${CONTAINER:-database-account-operator-tls-postgres}
+PORT=${PORT:-5433}
+DIR=${DIR:-$(mktemp -d)}
+CREATE_RESOURCES=${CREATE_RESOURCES:-true}
+
+cd "${DIR}"
+
+openssl req -x509 -new -nodes -newkey rsa:2048 -days 30 -subj "/CN=database-account-operator-ca" \
+  -keyout ca.key -out ca.crt
+
+openssl req -new -nodes -newkey rsa:2048 -subj "/CN=localhost" -keyout server.key -out server.csr
+printf "subjectAltName=DNS:localhost,IP:127.0.0.1" > server.ext
+openssl x509 -req -in server.csr -CA ca.crt -CAkey ca.key -CAcreateserial -days 30 -extfile server.ext -out server.crt
+
+openssl req -new -nodes -newkey rsa:2048 -subj "/CN=postgres" -keyout client.key -out client.csr
+openssl x509 -req -in client.csr -CA ca.crt -CAkey ca.key -CAcreateserial -days 30 -out client.crt
+
+cat > pg_hba.conf <<EOF
+local   all all              trust
+hostssl all all 0.0.0.0/0    cert clientcert=verify-full
+hostssl all all ::/0         cert clientcert=verify-full
+EOF
+
+# postgres refuses a key it does not own or readable by others, so the certificates are copied inside the container
+docker run -d --rm --name "${CONTAINER}" -p "${PORT}:5432" \
+  -e POSTGRES_PASSWORD=unused \
+  -v "${DIR}:/certs:ro" \
+  --entrypoint bash \
+  postgres:14 \
+  -c 'mkdir -p /etc/postgresql/tls && cp /certs/ca.crt /certs/server.crt /certs/server.key /certs/pg_hba.conf /etc/postgresql/tls/ &&
+      chown -R postgres /etc/postgresql/tls && chmod 0600 /etc/postgresql/tls/server.key &&
+      exec docker-entrypoint.sh postgres -c ssl=on \
+        -c ssl_ca_file=/etc/postgresql/tls/ca.crt \
+        -c ssl_cert_file=/etc/postgresql/tls/server.crt \
+        -c ssl_key_file=/etc/postgresql/tls/server.key \
+        -c hba_file=/etc/postgresql/tls/pg_hba.conf'
+
+if [ "${CREATE_RESOURCES}" = "true" ]; then
+  kubectl -n "${NAMESPACE}" create configmap postgres-tls-ca --from-file=ca.crt=ca.crt \
+    --dry-run=client -o yaml | kubectl apply -f -
+  kubectl -n "${NAMESPACE}" create secret tls postgres-tls-client --cert=client.crt --key=client.key \
+    --dry-run=client -o yaml | kubectl apply -f -
+fi
+
+echo "postgres listening on localhost:${PORT} with the certificates in ${DIR}"
+echo "connect with sslMode verify-full, the ConfigMap postgres-tls-ca and the Secret postgres-tls-client"
@@ -17,9 +17,9 @@ limitations under the License.
 package main
 
 import (
-	"database/sql"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -34,6 +34,7 @@ import (
 
 	databaseaccountoperatorv1 "database-account-operator/api/v1"
 	"database-account-operator/controllers"
+	"database-account-operator/pkg/scram"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -53,11 +54,33 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var defaultEncoding, defaultLCCollate, defaultLCCType string
+	var poolOptions controllers.PoolOptions
+	var healthCheckInterval, resyncInterval time.Duration
+	var scramIterations int
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&defaultEncoding, "default-encoding", "UTF8",
+		"Encoding set by the defaulting webhook on the PostgreSQLDatabases omitting it, empty to use the server default.")
+	flag.StringVar(&defaultLCCollate, "default-lc-collate", "en_US.UTF-8",
+		"LC_COLLATE set by the defaulting webhook on the PostgreSQLDatabases omitting it, empty to use the server default.")
+	flag.StringVar(&defaultLCCType, "default-lc-ctype", "en_US.UTF-8",
+		"LC_CTYPE set by the defaulting webhook on the PostgreSQLDatabases omitting it, empty to use the server default.")
+	flag.IntVar(&poolOptions.MaxOpenConns, "db-max-open-conns", 10,
+		"Maximum number of open connections of each connection pool, 0 for unlimited.")
+	flag.IntVar(&poolOptions.MaxIdleConns, "db-max-idle-conns", 2,
+		"Maximum number of idle connections kept by each connection pool.")
+	flag.DurationVar(&poolOptions.ConnMaxLifetime, "db-conn-max-lifetime", 30*time.Minute,
+		"Maximum amount of time a connection may be reused, 0 to reuse connections forever.")
+	flag.DurationVar(&healthCheckInterval, "db-health-check-interval", time.Minute,
+		"Interval between the pings checking the connection pools, 0 to disable them.")
+	flag.DurationVar(&resyncInterval, "resync-interval", 10*time.Minute,
+		"Interval between the reconciles detecting and correcting the changes made to the PostgreSQL objects outside of the operator, 0 to disable them.")
+	flag.IntVar(&scramIterations, "scram-iterations", scram.DefaultIterations,
+		"Iteration count of the SCRAM-SHA-256 verifiers sent instead of the passwords of the PostgreSQLAccounts.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -79,32 +102,72 @@ func main() {
 		os.Exit(1)
 	}
 
-	dbClients := make(map[string]*sql.DB)
+	connections := controllers.NewConnectionRegistry(poolOptions, healthCheckInterval)
+	if err = mgr.Add(connections); err != nil {
+		setupLog.Error(err, "unable to set up connection registry")
+		os.Exit(1)
+	}
 
+	if err = (&controllers.PostgreSQLServerReconciler{
+		Connections:    connections,
+		ResyncInterval: resyncInterval,
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PostgreSQLServer")
+		os.Exit(1)
+	}
 	if err = (&controllers.PostgreSQLDatabaseReconciler{
-		DBClients: &dbClients,
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
+		Connections:    connections,
+		Recorder:       mgr.GetEventRecorderFor("postgresqldatabase-controller"),
+		ResyncInterval: resyncInterval,
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PostgreSQLDatabase")
 		os.Exit(1)
 	}
 	if err = (&controllers.PostgreSQLAccountReconciler{
-		DBClients: &dbClients,
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
+		Connections:     connections,
+		Recorder:        mgr.GetEventRecorderFor("postgresqlaccount-controller"),
+		ResyncInterval:  resyncInterval,
+		ScramIterations: scramIterations,
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PostgreSQLAccount")
 		os.Exit(1)
 	}
 	if err = (&controllers.PostgreSQLGrantReconciler{
-		DBClients: &dbClients,
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
+		Connections:    connections,
+		Recorder:       mgr.GetEventRecorderFor("postgresqlgrant-controller"),
+		ResyncInterval: resyncInterval,
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PostgreSQLGrant")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&controllers.PostgreSQLDatabaseWebhook{
+			DefaultEncoding:  defaultEncoding,
+			DefaultLCCollate: defaultLCCollate,
+			DefaultLCCType:   defaultLCCType,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgreSQLDatabase")
+			os.Exit(1)
+		}
+		if err = (&controllers.PostgreSQLAccountWebhook{
+			Client: mgr.GetClient(),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgreSQLAccount")
+			os.Exit(1)
+		}
+		if err = (&controllers.PostgreSQLGrantWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgreSQLGrant")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
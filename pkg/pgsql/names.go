@@ -0,0 +1,44 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pgsql
+
+import (
+	"regexp"
+)
+
+// TablePrivileges are the privileges that can be granted on tables, ALL standing for all of them
+var TablePrivileges = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
+
+// MaxNameLength is the length in bytes past which PostgreSQL truncates identifiers, NAMEDATALEN - 1
+const MaxNameLength = 63
+
+// TODO: support other names supported by postgres
+var regexName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+var regexLocale = regexp.MustCompile(`^((C|POSIX|[A-Za-z]{2,3}(_[A-Za-z]{2})?)(\.[A-Za-z0-9-]+)?(@[A-Za-z0-9]+)?)?$`)
+
+// ValidName tells whether name is a role, database or schema name the operator accepts. The names PostgreSQL would
+// truncate are rejected, the truncated name being another object.
+func ValidName(name string) bool {
+	return len(name) <= MaxNameLength && regexName.MatchString(name)
+}
+
+// ValidLocale tells whether locale is a locale name the operator accepts, the empty locale meaning the server
+// default
+func ValidLocale(locale string) bool {
+	return regexLocale.MatchString(locale)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pgsql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"app", true},
+		{"App_2", true},
+		{"", false},
+		{"a.b", false},
+		{"a-b", false},
+		{`a"b`, false},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+	}
+	for _, tt := range tests {
+		if got := ValidName(tt.name); got != tt.want {
+			t.Errorf("ValidName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   bool
+	}{
+		{"", true},
+		{"C", true},
+		{"POSIX", true},
+		{"C.UTF-8", true},
+		{"C.utf8", true},
+		{"en_US", true},
+		{"en_US.UTF-8", true},
+		{"de_DE.utf8@euro", true},
+		{"English_United States.1252", false},
+		{"en_US.UTF-8'", false},
+	}
+	for _, tt := range tests {
+		if got := ValidLocale(tt.locale); got != tt.want {
+			t.Errorf("ValidLocale(%q) = %v, want %v", tt.locale, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pgsql quotes the identifiers and literals of the statements PostgreSQL does not accept parameters in,
+// like CREATE ROLE, CREATE DATABASE or GRANT, following the semantics of its quote_ident and quote_literal functions.
+// The server ends a statement at its first NUL byte, so a quoted value containing one is rejected as unterminated
+// rather than changing the meaning of the statement.
+// It also holds the names, locales and table privileges accepted by both the reconcilers and the importer.
+package pgsql
+
+import (
+	"strings"
+)
+
+// QuoteIdent quotes name as an identifier, doubling the double quotes it contains. Quoted identifiers are case
+// sensitive, so name must be spelled as it is stored in the catalogs.
+func QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteLiteral quotes value as a string literal, doubling the single quotes it contains.
+// A value containing backslashes is written as an escape string with the backslashes doubled, which reads the same
+// whatever standard_conforming_strings is set to.
+func QuoteLiteral(value string) string {
+	value = strings.ReplaceAll(value, `'`, `''`)
+	if strings.Contains(value, `\`) {
+		return `E'` + strings.ReplaceAll(value, `\`, `\\`) + `'`
+	}
+	return `'` + value + `'`
+}
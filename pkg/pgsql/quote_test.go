@@ -0,0 +1,64 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pgsql
+
+import "testing"
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{`users`, `"users"`},
+		{`Users`, `"Users"`},
+		{``, `""`},
+		{`a"b`, `"a""b"`},
+		{`"`, `""""`},
+		{`it's`, `"it's"`},
+		{`a\b`, `"a\b"`},
+		{`x"; DROP ROLE x; --`, `"x""; DROP ROLE x; --"`},
+		{`'; DROP ROLE x; --`, `"'; DROP ROLE x; --"`},
+		{"a\x00b", "\"a\x00b\""},
+	}
+	for _, tt := range tests {
+		if got := QuoteIdent(tt.name); got != tt.want {
+			t.Errorf("QuoteIdent(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{`secret`, `'secret'`},
+		{``, `''`},
+		{`it's`, `'it''s'`},
+		{`'`, `''''`},
+		{`a"b`, `'a"b'`},
+		{`'; DROP ROLE x; --`, `'''; DROP ROLE x; --'`},
+		{`a\b`, `E'a\\b'`},
+		{`\`, `E'\\'`},
+		{`\'`, `E'\\'''`},
+		{`\'; DROP ROLE x; --`, `E'\\''; DROP ROLE x; --'`},
+		{"a\x00b", "'a\x00b'"},
+	}
+	for _, tt := range tests {
+		if got := QuoteLiteral(tt.value); got != tt.want {
+			t.Errorf("QuoteLiteral(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
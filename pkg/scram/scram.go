@@ -0,0 +1,205 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scram computes the SCRAM-SHA-256 verifiers PostgreSQL stores in pg_authid.rolpassword, so the passwords
+// can be set without sending them in plain text to the server.
+package scram
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// DefaultIterations is the iteration count PostgreSQL uses by default
+	DefaultIterations = 4096
+	saltLength        = 16
+	prefix            = "SCRAM-SHA-256$"
+)
+
+// Verifier returns the verifier of password with a random salt, in the
+// SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey> format accepted by CREATE ROLE and ALTER ROLE
+func Verifier(password string, iterations int) (string, error) {
+	if iterations < 1 {
+		return "", fmt.Errorf(`invalid iteration count %d`, iterations)
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf(`error generating salt : %w`, err)
+	}
+	storedKey, serverKey := keys(password, salt, iterations)
+	return fmt.Sprintf("%s%d:%s$%s:%s", prefix, iterations, base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(storedKey), base64.StdEncoding.EncodeToString(serverKey)), nil
+}
+
+// Verify tells whether verifier was computed from password. It is false for the verifiers in other formats,
+// like the md5 ones.
+func Verify(verifier, password string) bool {
+	if !strings.HasPrefix(verifier, prefix) {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(verifier, prefix), "$")
+	if len(parts) != 2 {
+		return false
+	}
+	iterationsSalt := strings.SplitN(parts[0], ":", 2)
+	storedServerKeys := strings.SplitN(parts[1], ":", 2)
+	if len(iterationsSalt) != 2 || len(storedServerKeys) != 2 {
+		return false
+	}
+	iterations, err := strconv.Atoi(iterationsSalt[0])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(iterationsSalt[1])
+	if err != nil {
+		return false
+	}
+	storedKey, serverKey := keys(password, salt, iterations)
+	return subtle.ConstantTimeCompare([]byte(base64.StdEncoding.EncodeToString(storedKey)), []byte(storedServerKeys[0])) == 1 &&
+		subtle.ConstantTimeCompare([]byte(base64.StdEncoding.EncodeToString(serverKey)), []byte(storedServerKeys[1])) == 1
+}
+
+func keys(password string, salt []byte, iterations int) (storedKey, serverKey []byte) {
+	saltedPassword := pbkdf2.Key([]byte(normalize(password)), salt, iterations, sha256.Size, sha256.New)
+	clientKey := hmacSHA256(saltedPassword, "Client Key")
+	stored := sha256.Sum256(clientKey)
+	return stored[:], hmacSHA256(saltedPassword, "Server Key")
+}
+
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// normalize applies the mappings and the normalization of SASLprep like PostgreSQL does before hashing a password,
+// leaving the ASCII passwords untouched. Like PostgreSQL, the password is used as is when it is not valid UTF-8 or
+// when the normalized password holds a prohibited character or breaks the bidirectional rules. The unassigned code
+// points of Unicode 3.2 PostgreSQL also prohibits are not checked.
+func normalize(password string) string {
+	if !utf8.ValidString(password) {
+		return password
+	}
+	ascii := true
+	for _, c := range password {
+		if c > unicode.MaxASCII {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return password
+	}
+	mapped := strings.Map(func(c rune) rune {
+		switch {
+		case c == 0x00AD, c == 0x034F, c == 0x1806, c >= 0x180B && c <= 0x180D, c >= 0x200B && c <= 0x200D,
+			c == 0x2060, c >= 0xFE00 && c <= 0xFE0F, c == 0xFEFF:
+			// commonly mapped to nothing
+			return -1
+		case c == 0x00A0, c == 0x1680, c >= 0x2000 && c <= 0x200A, c == 0x202F, c == 0x205F, c == 0x3000:
+			// non-ASCII spaces
+			return ' '
+		}
+		return c
+	}, password)
+	normalized := norm.NFKC.String(mapped)
+	if normalized == "" || !allowed(normalized) {
+		return password
+	}
+	return normalized
+}
+
+// allowed checks the normalized password holds none of the prohibited characters of SASLprep, RFC 4013 section 2.3,
+// and follows the bidirectional rules of RFC 3454 section 6
+func allowed(password string) bool {
+	var randAL, l bool
+	for _, c := range password {
+		if prohibited(c) {
+			return false
+		}
+		properties, _ := bidi.LookupRune(c)
+		switch properties.Class() {
+		case bidi.R, bidi.AL:
+			randAL = true
+		case bidi.L:
+			l = true
+		}
+	}
+	if !randAL {
+		return true
+	}
+	// a password with right to left characters holds no left to right ones, and starts and ends with one
+	first, _ := utf8.DecodeRuneInString(password)
+	last, _ := utf8.DecodeLastRuneInString(password)
+	return !l && rightToLeft(first) && rightToLeft(last)
+}
+
+func rightToLeft(c rune) bool {
+	properties, _ := bidi.LookupRune(c)
+	return properties.Class() == bidi.R || properties.Class() == bidi.AL
+}
+
+// prohibited tells whether c is in the tables C.1.2 to C.9 of RFC 3454
+func prohibited(c rune) bool {
+	switch {
+	case c == 0x00A0, c == 0x1680, c >= 0x2000 && c <= 0x200B, c == 0x202F, c == 0x205F, c == 0x3000:
+		// C.1.2 non-ASCII spaces
+		return true
+	case c <= 0x001F, c == 0x007F:
+		// C.2.1 ASCII controls
+		return true
+	case c >= 0x0080 && c <= 0x009F, c == 0x06DD, c == 0x070F, c == 0x180E, c == 0x200C, c == 0x200D,
+		c == 0x2028, c == 0x2029, c >= 0x2060 && c <= 0x2063, c >= 0x206A && c <= 0x206F, c == 0xFEFF,
+		c >= 0xFFF9 && c <= 0xFFFC, c >= 0x1D173 && c <= 0x1D17A:
+		// C.2.2 non-ASCII controls
+		return true
+	case c >= 0xE000 && c <= 0xF8FF, c >= 0xF0000 && c <= 0xFFFFD, c >= 0x100000 && c <= 0x10FFFD:
+		// C.3 private use
+		return true
+	case c >= 0xFDD0 && c <= 0xFDEF, c&0xFFFE == 0xFFFE:
+		// C.4 non-characters
+		return true
+	case c >= 0xD800 && c <= 0xDFFF:
+		// C.5 surrogates
+		return true
+	case c >= 0xFFF9 && c <= 0xFFFD:
+		// C.6 inappropriate for plain text
+		return true
+	case c >= 0x2FF0 && c <= 0x2FFB:
+		// C.7 inappropriate for canonical representation
+		return true
+	case c == 0x0340, c == 0x0341, c == 0x200E, c == 0x200F, c >= 0x202A && c <= 0x202E:
+		// C.8 change display properties or deprecated, 206A-206F being in C.2.2
+		return true
+	case c == 0xE0001, c >= 0xE0020 && c <= 0xE007F:
+		// C.9 tagging characters
+		return true
+	}
+	return false
+}
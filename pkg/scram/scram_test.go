@@ -0,0 +1,157 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scram
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// The SCRAM-SHA-256 exchange of RFC 7677 section 3, authenticating with the password pencil
+const (
+	rfcPassword    = "pencil"
+	rfcSalt        = "W22ZaJ0SNY7soEsUEjb6gQ=="
+	rfcIterations  = 4096
+	rfcAuthMessage = "n=user,r=rOprNGfwEbeRWgbNEkqO," +
+		"r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096," +
+		"c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	rfcClientProof     = "dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	rfcServerSignature = "6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+)
+
+// rfcVerifier returns the verifier of the password with the salt of RFC 7677
+func rfcVerifier(t *testing.T, password string) string {
+	salt, err := base64.StdEncoding.DecodeString(rfcSalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedKey, serverKey := keys(password, salt, rfcIterations)
+	return fmt.Sprintf("%s%d:%s$%s:%s", prefix, rfcIterations, rfcSalt,
+		base64.StdEncoding.EncodeToString(storedKey), base64.StdEncoding.EncodeToString(serverKey))
+}
+
+func TestKeysKnownAnswer(t *testing.T) {
+	salt, _ := base64.StdEncoding.DecodeString(rfcSalt)
+	storedKey, serverKey := keys(rfcPassword, salt, rfcIterations)
+
+	// the server proves it holds ServerKey with the signature of the exchange
+	if signature := base64.StdEncoding.EncodeToString(hmacSHA256(serverKey, rfcAuthMessage)); signature != rfcServerSignature {
+		t.Errorf("server signature = %s, want %s", signature, rfcServerSignature)
+	}
+	// and checks the proof of the client recovers a ClientKey hashing to StoredKey
+	proof, _ := base64.StdEncoding.DecodeString(rfcClientProof)
+	clientKey := hmacSHA256(storedKey, rfcAuthMessage)
+	for i := range clientKey {
+		clientKey[i] ^= proof[i]
+	}
+	if hash := sha256.Sum256(clientKey); !bytes.Equal(hash[:], storedKey) {
+		t.Errorf("client proof does not match StoredKey %s", base64.StdEncoding.EncodeToString(storedKey))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	verifier := rfcVerifier(t, rfcPassword)
+	tests := []struct {
+		verifier, password string
+		want               bool
+	}{
+		{verifier, rfcPassword, true},
+		{verifier, "Pencil", false},
+		{verifier, "", false},
+		{"md5" + strings.Repeat("0", 32), rfcPassword, false},
+		{strings.Replace(verifier, "4096:", "4097:", 1), rfcPassword, false},
+		{strings.Replace(verifier, "4096:", "0:", 1), rfcPassword, false},
+		{strings.Replace(verifier, "4096:", "x:", 1), rfcPassword, false},
+		{strings.Replace(verifier, rfcSalt, "not base64", 1), rfcPassword, false},
+		{strings.SplitN(verifier, ":", 3)[0] + ":" + strings.SplitN(verifier, ":", 3)[1], rfcPassword, false},
+		{prefix, rfcPassword, false},
+	}
+	for _, tt := range tests {
+		if got := Verify(tt.verifier, tt.password); got != tt.want {
+			t.Errorf("Verify(%q, %q) = %v, want %v", tt.verifier, tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	verifier, err := Verifier(rfcPassword, DefaultIterations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(verifier, "SCRAM-SHA-256$4096:") {
+		t.Errorf("verifier %s does not have the SCRAM-SHA-256$4096: prefix", verifier)
+	}
+	if !Verify(verifier, rfcPassword) || Verify(verifier, "Pencil") {
+		t.Errorf("verifier %s does not verify only %s", verifier, rfcPassword)
+	}
+	other, _ := Verifier(rfcPassword, DefaultIterations)
+	if other == verifier {
+		t.Errorf("verifiers of the same password share the salt")
+	}
+	if _, err = Verifier(rfcPassword, 0); err == nil {
+		t.Errorf("Verifier accepted 0 iterations")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		password, want string
+	}{
+		{"pencil", "pencil"},
+		// ASCII passwords are left untouched, control characters included
+		{"pen\tcil\x7f", "pen\tcil\x7f"},
+		{"pen\u00ADcil", "pencil"},
+		{"pen\u200Bcil\uFEFF", "pencil"},
+		{"pen\u00A0cil", "pen cil"},
+		{"pen\u3000cil", "pen cil"},
+		{"\u2168", "IX"},
+		{"\uFB01sh", "fish"},
+		{"e\u0301", "\u00E9"},
+		{"\u00E9", "\u00E9"},
+		// the passwords still holding a prohibited character or breaking the bidirectional rules once normalized
+		// are used as is
+		{"p\u00E9n\tcil", "p\u00E9n\tcil"},
+		{"pen\uE000cil\u2168", "pen\uE000cil\u2168"},
+		{"pen\u200Ecil\u2168", "pen\u200Ecil\u2168"},
+		{"\u0627\u0031\u0628", "\u0627\u0031\u0628"},
+		{"\u0627\u0031\u2168\u0628", "\u0627\u0031\u2168\u0628"},
+		{"\u0627\u0031", "\u0627\u0031"},
+		{"\uFB50\u0031\uFB50", "\u0671\u0031\u0671"},
+		{"\u0627\u00AD", "\u0627"},
+		{"pen\xffcil\u2168", "pen\xffcil\u2168"},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.password); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+
+	// the verifiers of the passwords normalizing to the same string verify each other
+	verifier := rfcVerifier(t, "p\u00E9ncil\u2168")
+	for _, password := range []string{"pe\u0301ncil\u2168", "p\u00E9n\u00ADcilIX"} {
+		if !Verify(verifier, password) {
+			t.Errorf("Verify does not accept %q for the verifier of %q", password, "p\u00E9ncil\u2168")
+		}
+	}
+	if Verify(verifier, "pencilIX") {
+		t.Errorf("Verify accepts %q for the verifier of %q", "pencilIX", "p\u00E9ncil\u2168")
+	}
+}